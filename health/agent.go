@@ -0,0 +1,145 @@
+package health
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+// AgentOptions contain optional agent settings
+type AgentOptions struct {
+	// DegradedWeight is the weight, in percent, announced
+	// while the check is degraded. Default: 50
+	DegradedWeight int
+	// WriteTimeout limits the time spent on writing a response. Default: 1s
+	WriteTimeout time.Duration
+}
+
+func (o *AgentOptions) norm() *AgentOptions {
+	var oo AgentOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.DegradedWeight <= 0 {
+		oo.DegradedWeight = 50
+	} else if oo.DegradedWeight > 100 {
+		oo.DegradedWeight = 100
+	}
+	if oo.WriteTimeout <= 0 {
+		oo.WriteTimeout = time.Second
+	}
+	return &oo
+}
+
+// Agent serves the state of a check via the HAProxy agent-check protocol.
+// Each connection receives a single line response before it is closed:
+//
+//	up ready 100%                      - healthy
+//	up ready 50% #cache is unhealthy   - degraded, see AgentOptions
+//	down ready #db is unhealthy        - unhealthy
+//	drain                              - draining, see Drain
+//
+// All responses other than drain include "ready", which restores the
+// administrative state in HAProxy once draining has been disabled.
+//
+// Agents are typically backed by a Registry, e.g.:
+//
+//	agent, err := health.ListenAgent(":9707", reg, nil)
+//
+// with a matching HAProxy server configuration:
+//
+//	server app1 10.0.0.1:8080 check agent-check agent-port 9707 agent-inter 2s
+type Agent struct {
+	check Check
+	lis   net.Listener
+	opts  *AgentOptions
+
+	closer tomb.Tomb
+}
+
+// ListenAgent starts an agent on a TCP address.
+// Options are optional and may be nil.
+func ListenAgent(addr string, check Check, opts *AgentOptions) (*Agent, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewAgent(lis, check, opts), nil
+}
+
+// NewAgent starts an agent on a custom listener.
+// Options are optional and may be nil.
+func NewAgent(lis net.Listener, check Check, opts *AgentOptions) *Agent {
+	a := &Agent{check: check, lis: lis, opts: opts.norm()}
+	a.closer.Go(a.serve)
+	return a
+}
+
+// Addr returns the listener address
+func (a *Agent) Addr() net.Addr {
+	return a.lis.Addr()
+}
+
+// Stop closes the listener and stops the agent
+func (a *Agent) Stop() error {
+	a.closer.Kill(nil)
+	err := a.lis.Close()
+	_ = a.closer.Wait()
+	return err
+}
+
+func (a *Agent) serve() error {
+	for {
+		conn, err := a.lis.Accept()
+		if err != nil {
+			select {
+			case <-a.closer.Dying():
+				return nil
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		a.closer.Go(func() error {
+			a.handle(conn)
+			return nil
+		})
+	}
+}
+
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(a.opts.WriteTimeout))
+	_, _ = conn.Write([]byte(a.response() + "\n"))
+}
+
+func (a *Agent) response() string {
+	if d, ok := a.check.(drainer); ok && d.IsDraining() {
+		return "drain"
+	}
+
+	status, reason := StatusOf(a.check)
+	var res string
+	switch status {
+	case StatusHealthy:
+		return "up ready 100%"
+	case StatusDegraded:
+		res = "up ready " + strconv.Itoa(a.opts.DegradedWeight) + "%"
+	default:
+		res = "down ready"
+	}
+
+	if reason = strings.Join(strings.Fields(reason), " "); reason != "" {
+		res += " #" + reason
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"io/ioutil"
+	"net"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Agent", func() {
+	var subject *Agent
+	var reg *Registry
+	var drain *Drain
+	var db, cache int32
+
+	flag := func(v *int32) Check {
+		return CheckFunc(func() bool { return atomic.LoadInt32(v) > 0 })
+	}
+
+	query := func() string {
+		conn, err := net.Dial("tcp", subject.Addr().String())
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer conn.Close()
+
+		data, err := ioutil.ReadAll(conn)
+		g.Expect(err).NotTo(g.HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&db, 1)
+		atomic.StoreInt32(&cache, 1)
+		drain = NewDrain()
+
+		reg = NewRegistry()
+		g.Expect(reg.Register("db", flag(&db), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register("cache", flag(&cache), nil)).To(g.Succeed())
+		g.Expect(reg.Register("drain", drain, &CheckOptions{Critical: true})).To(g.Succeed())
+
+		var err error
+		subject, err = ListenAgent("127.0.0.1:0", reg, &AgentOptions{DegradedWeight: 25})
+		g.Expect(err).NotTo(g.HaveOccurred())
+	})
+
+	AfterEach(func() {
+		g.Expect(subject.Stop()).To(g.Succeed())
+	})
+
+	It("should report healthy", func() {
+		g.Expect(query()).To(g.Equal("up ready 100%\n"))
+	})
+
+	It("should report degraded", func() {
+		atomic.StoreInt32(&cache, 0)
+		g.Expect(query()).To(g.Equal("up ready 25% #cache is unhealthy\n"))
+	})
+
+	It("should report unhealthy", func() {
+		atomic.StoreInt32(&db, 0)
+		atomic.StoreInt32(&cache, 0)
+		g.Expect(query()).To(g.Equal("down ready #cache is unhealthy, db is unhealthy\n"))
+	})
+
+	It("should report draining", func() {
+		drain.Enable()
+		g.Expect(query()).To(g.Equal("drain\n"))
+
+		drain.Disable()
+		g.Expect(query()).To(g.Equal("up ready 100%\n"))
+	})
+
+	It("should restore readiness after draining", func() {
+		drain.Enable()
+		g.Expect(query()).To(g.Equal("drain\n"))
+
+		drain.Disable()
+		atomic.StoreInt32(&cache, 0)
+		g.Expect(query()).To(g.HavePrefix("up ready 25%"))
+
+		atomic.StoreInt32(&db, 0)
+		g.Expect(query()).To(g.HavePrefix("down ready"))
+	})
+
+	It("should serve plain checks", func() {
+		agent := NewAgent(mustListen(), flag(&db), nil)
+		defer agent.Stop()
+		g.Expect(agent.response()).To(g.Equal("up ready 100%"))
+
+		atomic.StoreInt32(&db, 0)
+		g.Expect(agent.response()).To(g.Equal("down ready"))
+	})
+
+})
+
+func mustListen() net.Listener {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(g.HaveOccurred())
+	return lis
+}
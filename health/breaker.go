@@ -0,0 +1,200 @@
+package health
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int32
+
+// Available breaker states
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+// String returns the state name
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOptions contain optional breaker settings
+type BreakerOptions struct {
+	// Threshold is the number of subsequent failures that trip the breaker. Default: 5
+	Threshold int
+	// CoolDown is the time the breaker stays open before trial calls are allowed. Default: 10s
+	CoolDown time.Duration
+	// Trials is the number of concurrent trial calls allowed while half-open. Default: 1
+	Trials int
+	// Rise is the number of subsequent successful trial calls required to close the breaker. Default: 1
+	Rise int
+}
+
+func (o *BreakerOptions) norm() *BreakerOptions {
+	var oo BreakerOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.Threshold < 1 {
+		oo.Threshold = 5
+	}
+	if oo.CoolDown <= 0 {
+		oo.CoolDown = 10 * time.Second
+	}
+	if oo.Trials < 1 {
+		oo.Trials = 1
+	}
+	if oo.Rise < 1 {
+		oo.Rise = 1
+	}
+	return &oo
+}
+
+// Breaker is a circuit breaker which is fed with the outcomes of calls by the
+// application. It trips open after a number of subsequent failures and rejects
+// calls until the cool-down has passed. It then moves to half-open and allows
+// trial calls to pass. Successful trials close the breaker, a failed trial
+// re-opens it.
+//
+// A breaker is healthy unless it is open. An open breaker reports as half-open
+// once the cool-down has passed, even if no calls have been attempted since.
+type Breaker struct {
+	opts *BreakerOptions
+	now  func() time.Time
+
+	state    int32
+	openedAt int64 // unix nanos
+
+	fails, trials, successes int
+	mu                       sync.Mutex
+}
+
+// NewBreaker creates a new breaker. Options are optional and may be nil.
+func NewBreaker(opts *BreakerOptions) *Breaker {
+	return &Breaker{opts: opts.norm(), now: time.Now}
+}
+
+// State returns the current state
+func (b *Breaker) State() BreakerState {
+	s := b.storedState()
+	if s == BreakerOpen && b.cooledDown() {
+		return BreakerHalfOpen
+	}
+	return s
+}
+
+// IsHealthy implements Check interface
+func (b *Breaker) IsHealthy() bool {
+	return b.State() != BreakerOpen
+}
+
+// CheckStatus implements StatusChecker interface
+func (b *Breaker) CheckStatus() (Status, string) {
+	switch b.State() {
+	case BreakerOpen:
+		return StatusUnhealthy, "circuit is open"
+	case BreakerHalfOpen:
+		return StatusDegraded, "circuit is half-open"
+	}
+	return StatusHealthy, ""
+}
+
+// Allow must be called before each call and returns true if the call
+// may proceed. Each allowed call must be followed by either Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.storedState() {
+	case BreakerOpen:
+		if !b.cooledDown() {
+			return false
+		}
+		b.trials, b.successes = 1, 0
+		b.setState(BreakerHalfOpen)
+		return true
+	case BreakerHalfOpen:
+		if b.trials >= b.opts.Trials {
+			return false
+		}
+		b.trials++
+	}
+	return true
+}
+
+// Success records a successful call. While half-open, only outcomes of
+// admitted trial calls are counted, excess outcomes are ignored.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.storedState() {
+	case BreakerClosed:
+		b.fails = 0
+	case BreakerHalfOpen:
+		if b.trials < 1 {
+			return
+		}
+		b.trials--
+		if b.successes++; b.successes >= b.opts.Rise {
+			b.fails = 0
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// Failure records a failed call. While half-open, only outcomes of
+// admitted trial calls are counted, excess outcomes are ignored.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.storedState() {
+	case BreakerClosed:
+		if b.fails++; b.fails >= b.opts.Threshold {
+			b.trip()
+		}
+	case BreakerHalfOpen:
+		if b.trials > 0 {
+			b.trip()
+		}
+	}
+}
+
+// Record records the outcome of a call, a nil error is considered successful
+func (b *Breaker) Record(err error) {
+	if err != nil {
+		b.Failure()
+	} else {
+		b.Success()
+	}
+}
+
+func (b *Breaker) trip() {
+	atomic.StoreInt64(&b.openedAt, b.now().UnixNano())
+	b.setState(BreakerOpen)
+}
+
+func (b *Breaker) cooledDown() bool {
+	return b.now().UnixNano()-atomic.LoadInt64(&b.openedAt) >= int64(b.opts.CoolDown)
+}
+
+func (b *Breaker) storedState() BreakerState {
+	return BreakerState(atomic.LoadInt32(&b.state))
+}
+
+func (b *Breaker) setState(s BreakerState) {
+	atomic.StoreInt32(&b.state, int32(s))
+}
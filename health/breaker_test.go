@@ -0,0 +1,130 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Breaker", func() {
+	var subject *Breaker
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+		subject = NewBreaker(&BreakerOptions{Threshold: 3, CoolDown: time.Minute, Trials: 2, Rise: 2})
+		subject.now = func() time.Time { return now }
+	})
+
+	It("should trip after subsequent failures", func() {
+		g.Expect(subject.State()).To(g.Equal(BreakerClosed))
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		subject.Failure()
+		subject.Failure()
+		subject.Success()
+		subject.Failure()
+		subject.Failure()
+		g.Expect(subject.State()).To(g.Equal(BreakerClosed))
+		g.Expect(subject.Allow()).To(g.BeTrue())
+
+		subject.Record(errPing)
+		g.Expect(subject.State()).To(g.Equal(BreakerOpen))
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		g.Expect(subject.Allow()).To(g.BeFalse())
+	})
+
+	It("should allow trials after cool-down", func() {
+		for i := 0; i < 3; i++ {
+			subject.Failure()
+		}
+		now = now.Add(59 * time.Second)
+		g.Expect(subject.Allow()).To(g.BeFalse())
+
+		now = now.Add(time.Second)
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		g.Expect(subject.State()).To(g.Equal(BreakerHalfOpen))
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		g.Expect(subject.Allow()).To(g.BeFalse())
+
+		subject.Success()
+		g.Expect(subject.State()).To(g.Equal(BreakerHalfOpen))
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		subject.Record(nil)
+		g.Expect(subject.State()).To(g.Equal(BreakerClosed))
+	})
+
+	It("should re-open on failed trials", func() {
+		for i := 0; i < 3; i++ {
+			subject.Failure()
+		}
+		now = now.Add(time.Minute)
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		subject.Failure()
+		g.Expect(subject.State()).To(g.Equal(BreakerOpen))
+		g.Expect(subject.Allow()).To(g.BeFalse())
+	})
+
+	It("should become half-open after cool-down without calls", func() {
+		for i := 0; i < 3; i++ {
+			subject.Failure()
+		}
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		now = now.Add(time.Minute)
+		g.Expect(subject.State()).To(g.Equal(BreakerHalfOpen))
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+	})
+
+	It("should only count outcomes of trial calls", func() {
+		for i := 0; i < 3; i++ {
+			subject.Failure()
+		}
+		now = now.Add(time.Minute)
+
+		// stray outcomes before any trial was admitted
+		subject.Success()
+		subject.Failure()
+		g.Expect(subject.State()).To(g.Equal(BreakerHalfOpen))
+
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		subject.Success()
+		subject.Success() // stray
+		subject.Success() // stray
+		g.Expect(subject.State()).To(g.Equal(BreakerHalfOpen))
+
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		g.Expect(subject.Allow()).To(g.BeTrue())
+		g.Expect(subject.Allow()).To(g.BeFalse())
+	})
+
+	It("should check status", func() {
+		status, _ := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusHealthy))
+
+		for i := 0; i < 3; i++ {
+			subject.Failure()
+		}
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("circuit is open"))
+
+		now = now.Add(time.Minute)
+		subject.Allow()
+		status, _ = subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusDegraded))
+	})
+
+})
+
+func BenchmarkBreaker_IsHealthy(b *testing.B) {
+	breaker := NewBreaker(nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		breaker.IsHealthy()
+	}
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Cached returns a check which caches the result of fn for the duration of ttl.
+// The fn is called once, synchronously, on creation. Afterwards, IsHealthy
+// always returns the cached result immediately and, once the result has
+// expired, triggers a single asynchronous refresh. A panic in fn is treated
+// as unhealthy.
+//
+// Cached is a middle ground between CheckFunc and Ping for checks that are too
+// expensive to run on every request, but cheap enough to run on demand.
+func Cached(fn func() bool, ttl time.Duration) Check {
+	c := &cachedCheck{fn: fn, ttl: ttl, now: time.Now}
+	c.refresh()
+	return c
+}
+
+type cachedCheck struct {
+	fn  func() bool
+	ttl time.Duration
+	now func() time.Time
+
+	healthy, refreshing int32
+	expires             int64
+}
+
+// IsHealthy implements Check interface
+func (c *cachedCheck) IsHealthy() bool {
+	if c.now().UnixNano() > atomic.LoadInt64(&c.expires) && atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		go c.refresh()
+	}
+	return atomic.LoadInt32(&c.healthy) > 0
+}
+
+func (c *cachedCheck) refresh() {
+	defer atomic.StoreInt32(&c.refreshing, 0)
+
+	var healthy int32
+	if c.call() {
+		healthy = 1
+	}
+	atomic.StoreInt32(&c.healthy, healthy)
+	atomic.StoreInt64(&c.expires, c.now().Add(c.ttl).UnixNano())
+}
+
+func (c *cachedCheck) call() (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return c.fn()
+}
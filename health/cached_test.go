@@ -0,0 +1,67 @@
+package health
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cached", func() {
+
+	It("should evaluate on creation", func() {
+		var calls int32
+		check := Cached(func() bool { atomic.AddInt32(&calls, 1); return true }, time.Hour)
+		g.Expect(atomic.LoadInt32(&calls)).To(g.Equal(int32(1)))
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+		g.Expect(atomic.LoadInt32(&calls)).To(g.Equal(int32(1)))
+	})
+
+	It("should refresh stale results asynchronously", func() {
+		healthy := int32(1)
+		check := Cached(func() bool { return atomic.LoadInt32(&healthy) > 0 }, time.Millisecond)
+		atomic.StoreInt32(&healthy, 0)
+		time.Sleep(2 * time.Millisecond)
+
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+		g.Eventually(check.IsHealthy, "20ms", "2ms").Should(g.BeFalse())
+	})
+
+	It("should refresh at most once concurrently", func() {
+		var calls int32
+		release := make(chan struct{})
+		check := Cached(func() bool {
+			if atomic.AddInt32(&calls, 1) > 1 {
+				<-release
+			}
+			return true
+		}, time.Nanosecond).(*cachedCheck)
+		time.Sleep(time.Millisecond)
+
+		for i := 0; i < 10; i++ {
+			g.Expect(check.IsHealthy()).To(g.BeTrue())
+		}
+		g.Eventually(func() int32 { return atomic.LoadInt32(&calls) }, "20ms", "2ms").Should(g.Equal(int32(2)))
+		g.Consistently(func() int32 { return atomic.LoadInt32(&calls) }, "10ms", "2ms").Should(g.Equal(int32(2)))
+		close(release)
+		g.Eventually(func() int32 { return atomic.LoadInt32(&check.refreshing) }, "20ms", "2ms").Should(g.Equal(int32(0)))
+	})
+
+	It("should treat panics as unhealthy", func() {
+		check := Cached(func() bool { panic("boom") }, time.Hour)
+		g.Expect(check.IsHealthy()).To(g.BeFalse())
+	})
+
+})
+
+func BenchmarkCached_IsHealthy(b *testing.B) {
+	check := Cached(func() bool { return true }, time.Hour)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		check.IsHealthy()
+	}
+}
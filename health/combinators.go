@@ -0,0 +1,92 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+)
+
+// All returns a check which is healthy if all of the given checks are healthy.
+// The returned check also implements StatusChecker, see Quorum.
+func All(checks ...Check) Check {
+	return newQuorum(len(checks), checks)
+}
+
+// Any returns a check which is healthy if at least one of the given checks is healthy.
+// The returned check also implements StatusChecker, see Quorum.
+func Any(checks ...Check) Check {
+	return newQuorum(1, checks)
+}
+
+// Quorum returns a check which is healthy if at least n of the given checks are healthy.
+// The returned check also implements StatusChecker. While the quorum is met, it
+// reports the worst status of the given checks, but at most degraded.
+func Quorum(n int, checks ...Check) Check {
+	return newQuorum(n, checks)
+}
+
+// Not returns a check which inverts the given check.
+func Not(check Check) Check {
+	return not{check: check}
+}
+
+type quorum struct {
+	n      int
+	checks []Check
+}
+
+func newQuorum(n int, checks []Check) quorum {
+	return quorum{n: n, checks: append([]Check(nil), checks...)}
+}
+
+// IsHealthy implements Check interface
+func (q quorum) IsHealthy() bool {
+	if q.n <= 0 {
+		return true
+	}
+
+	healthy, remaining := 0, len(q.checks)
+	for _, c := range q.checks {
+		if c.IsHealthy() {
+			if healthy++; healthy >= q.n {
+				return true
+			}
+		}
+		// bail out early, once the quorum cannot be reached anymore
+		if remaining--; healthy+remaining < q.n {
+			return false
+		}
+	}
+	return false
+}
+
+// CheckStatus implements StatusChecker interface
+func (q quorum) CheckStatus() (Status, string) {
+	status, healthy := StatusHealthy, 0
+	var reasons []string
+	for _, c := range q.checks {
+		s, reason := StatusOf(c)
+		if s != StatusUnhealthy {
+			healthy++
+		}
+		if s == StatusHealthy {
+			continue
+		}
+		if s < status {
+			status = s
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if healthy < q.n {
+		msg := fmt.Sprintf("%d of %d checks healthy, %d required", healthy, len(q.checks), q.n)
+		return StatusUnhealthy, strings.Join(append([]string{msg}, reasons...), ", ")
+	}
+	return aggregateStatus(status, false), strings.Join(reasons, ", ")
+}
+
+type not struct{ check Check }
+
+// IsHealthy implements Check interface
+func (n not) IsHealthy() bool { return !n.check.IsHealthy() }
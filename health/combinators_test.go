@@ -0,0 +1,77 @@
+package health
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Combinators", func() {
+	pass := CheckFunc(func() bool { return true })
+	fail := CheckFunc(func() bool { return false })
+	slow := StatusCheckFunc(func() (Status, string) { return StatusDegraded, "slow" })
+	down := StatusCheckFunc(func() (Status, string) { return StatusUnhealthy, "down" })
+
+	DescribeTable("should combine checks",
+		func(check Check, expected bool) {
+			g.Expect(check.IsHealthy()).To(g.Equal(expected))
+		},
+
+		Entry("all, empty", All(), true),
+		Entry("all, passing", All(pass, pass), true),
+		Entry("all, failing", All(pass, fail), false),
+
+		Entry("any, empty", Any(), false),
+		Entry("any, passing", Any(fail, pass), true),
+		Entry("any, failing", Any(fail, fail), false),
+
+		Entry("quorum, zero", Quorum(0, fail), true),
+		Entry("quorum, passing", Quorum(2, pass, fail, pass), true),
+		Entry("quorum, failing", Quorum(2, fail, pass, fail), false),
+		Entry("quorum, too few", Quorum(3, pass, pass), false),
+
+		Entry("not, passing", Not(fail), true),
+		Entry("not, failing", Not(pass), false),
+
+		Entry("nested", All(Any(fail, pass), Not(fail)), true),
+	)
+
+	DescribeTable("should report status",
+		func(check Check, expStatus Status, expReason string) {
+			status, reason := check.(StatusChecker).CheckStatus()
+			g.Expect(status).To(g.Equal(expStatus))
+			g.Expect(reason).To(g.Equal(expReason))
+			g.Expect(check.IsHealthy()).To(g.Equal(status != StatusUnhealthy))
+		},
+
+		Entry("all, passing", All(pass, pass), StatusHealthy, ""),
+		Entry("all, degraded", All(pass, slow), StatusDegraded, "slow"),
+		Entry("all, failing", All(slow, down), StatusUnhealthy, "1 of 2 checks healthy, 2 required, slow, down"),
+		Entry("any, one failing", Any(down, pass), StatusDegraded, "down"),
+		Entry("any, failing", Any(down, fail), StatusUnhealthy, "0 of 2 checks healthy, 1 required, down"),
+		Entry("quorum, zero", Quorum(0, down), StatusDegraded, "down"),
+		Entry("nested", All(Any(down, pass), slow), StatusDegraded, "down, slow"),
+	)
+
+	It("should not be affected by changes to the given checks", func() {
+		checks := []Check{pass, pass}
+		check := All(checks...)
+		checks[1] = fail
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+	})
+
+})
+
+func BenchmarkQuorum_IsHealthy(b *testing.B) {
+	pass := CheckFunc(func() bool { return true })
+	fail := CheckFunc(func() bool { return false })
+	check := Quorum(2, fail, pass, fail, pass, pass)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		check.IsHealthy()
+	}
+}
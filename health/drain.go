@@ -0,0 +1,149 @@
+package health
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Drain is a maintenance switch which can be used to take an instance out of
+// a load balancer rotation, e.g. before deploys. A draining instance reports
+// as unhealthy, but may continue serving in-flight requests.
+//
+// Drain can be toggled programmatically, by signals or by the presence of a
+// sentinel file.
+type Drain struct {
+	draining int32
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewDrain creates a new drain switch
+func NewDrain() *Drain {
+	return &Drain{stop: make(chan struct{})}
+}
+
+// Enable enables drain mode
+func (d *Drain) Enable() { atomic.StoreInt32(&d.draining, 1) }
+
+// Disable disables drain mode
+func (d *Drain) Disable() { atomic.StoreInt32(&d.draining, 0) }
+
+// IsDraining returns true if drain mode is enabled
+func (d *Drain) IsDraining() bool { return atomic.LoadInt32(&d.draining) > 0 }
+
+// IsHealthy implements Check interface
+func (d *Drain) IsHealthy() bool { return !d.IsDraining() }
+
+// CheckStatus implements StatusChecker interface
+func (d *Drain) CheckStatus() (Status, string) {
+	if d.IsDraining() {
+		return StatusUnhealthy, "draining"
+	}
+	return StatusHealthy, ""
+}
+
+// Wrap wraps a check. The resulting check is unhealthy while draining and
+// reports the state of the wrapped check otherwise.
+func (d *Drain) Wrap(check Check) Check {
+	return drainCheck{drain: d, check: check}
+}
+
+// WatchSignals enables drain mode when the enable signal is received
+// and disables it on the disable signal, e.g.:
+//
+//	drain.WatchSignals(syscall.SIGUSR1, syscall.SIGUSR2)
+func (d *Drain) WatchSignals(enable, disable os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, enable, disable)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-d.stop:
+				return
+			case sig := <-ch:
+				switch sig {
+				case enable:
+					d.Enable()
+				case disable:
+					d.Disable()
+				}
+			}
+		}
+	}()
+}
+
+// WatchFile polls for the presence of a sentinel file every inter. Drain mode
+// is enabled when the file is created and disabled when it is removed.
+func (d *Drain) WatchFile(path string, inter time.Duration) {
+	exists := func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	last := exists()
+	if last {
+		d.Enable()
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(inter)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				if now := exists(); now != last {
+					if last = now; now {
+						d.Enable()
+					} else {
+						d.Disable()
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops all watchers
+func (d *Drain) Stop() {
+	d.once.Do(func() { close(d.stop) })
+	d.wg.Wait()
+}
+
+type drainCheck struct {
+	drain *Drain
+	check Check
+}
+
+// IsDraining returns true if drain mode is enabled
+func (c drainCheck) IsDraining() bool {
+	return c.drain.IsDraining()
+}
+
+// IsHealthy implements Check interface
+func (c drainCheck) IsHealthy() bool {
+	return !c.IsDraining() && c.check.IsHealthy()
+}
+
+// CheckStatus implements StatusChecker interface
+func (c drainCheck) CheckStatus() (Status, string) {
+	if c.IsDraining() {
+		return StatusUnhealthy, "draining"
+	}
+	return StatusOf(c.check)
+}
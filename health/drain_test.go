@@ -0,0 +1,95 @@
+package health
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drain", func() {
+	var subject *Drain
+
+	BeforeEach(func() {
+		subject = NewDrain()
+	})
+
+	AfterEach(func() {
+		subject.Stop()
+	})
+
+	It("should toggle", func() {
+		g.Expect(subject.IsDraining()).To(g.BeFalse())
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		subject.Enable()
+		g.Expect(subject.IsDraining()).To(g.BeTrue())
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("draining"))
+
+		subject.Disable()
+		g.Expect(subject.IsDraining()).To(g.BeFalse())
+	})
+
+	It("should wrap checks", func() {
+		healthy := true
+		check := subject.Wrap(CheckFunc(func() bool { return healthy }))
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+
+		healthy = false
+		g.Expect(check.IsHealthy()).To(g.BeFalse())
+		_, reason := StatusOf(check)
+		g.Expect(reason).To(g.BeEmpty())
+
+		healthy = true
+		subject.Enable()
+		g.Expect(check.IsHealthy()).To(g.BeFalse())
+		_, reason = StatusOf(check)
+		g.Expect(reason).To(g.Equal("draining"))
+	})
+
+	It("should integrate with registries", func() {
+		reg := NewRegistry()
+		g.Expect(reg.Register("db", subject.Wrap(CheckFunc(func() bool { return true })), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.IsDraining()).To(g.BeFalse())
+		g.Expect(reg.IsHealthy()).To(g.BeTrue())
+
+		subject.Enable()
+		g.Expect(reg.IsDraining()).To(g.BeTrue())
+		g.Expect(reg.IsHealthy()).To(g.BeFalse())
+	})
+
+	It("should watch signals", func() {
+		subject.WatchSignals(syscall.SIGUSR1, syscall.SIGUSR2)
+
+		g.Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR1)).To(g.Succeed())
+		g.Eventually(subject.IsDraining, "100ms", "2ms").Should(g.BeTrue())
+
+		g.Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR2)).To(g.Succeed())
+		g.Eventually(subject.IsDraining, "100ms", "2ms").Should(g.BeFalse())
+	})
+
+	It("should watch files", func() {
+		dir, err := ioutil.TempDir("", "flood-health")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "drain")
+		subject.WatchFile(path, time.Millisecond)
+		g.Expect(subject.IsDraining()).To(g.BeFalse())
+
+		g.Expect(ioutil.WriteFile(path, nil, 0644)).To(g.Succeed())
+		g.Eventually(subject.IsDraining, "100ms", "2ms").Should(g.BeTrue())
+
+		g.Expect(os.Remove(path)).To(g.Succeed())
+		g.Eventually(subject.IsDraining, "100ms", "2ms").Should(g.BeFalse())
+	})
+
+})
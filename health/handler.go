@@ -0,0 +1,72 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// TagLiveness can be used to tag checks that should be included in liveness
+// reports. Liveness should only fail if the process is broken beyond repair and
+// must be restarted.
+const TagLiveness = "liveness"
+
+// HandlerOptions contain optional handler settings
+type HandlerOptions struct {
+	// Tags limits the view to checks tagged with at least one of the given tags.
+	// Default: all checks
+	Tags []string
+	// Verbose enables a JSON body listing the state of each check. Clients may
+	// also request a verbose response by passing a 'verbose' query parameter.
+	Verbose bool
+}
+
+// NewHandler creates a HTTP handler which serves the state of the registry.
+// It responds with 200 if all critical checks are healthy and with 503 otherwise.
+func NewHandler(reg *Registry, opts *HandlerOptions) http.Handler {
+	h := &handler{reg: reg}
+	if opts != nil {
+		h.tags = opts.Tags
+		h.verbose = opts.Verbose
+	}
+	return h
+}
+
+// NewLivenessHandler creates a handler which only considers checks
+// tagged with TagLiveness.
+func NewLivenessHandler(reg *Registry) http.Handler {
+	return NewHandler(reg, &HandlerOptions{Tags: []string{TagLiveness}})
+}
+
+// NewReadinessHandler creates a handler which considers all checks.
+func NewReadinessHandler(reg *Registry) http.Handler {
+	return NewHandler(reg, nil)
+}
+
+type handler struct {
+	reg     *Registry
+	tags    []string
+	verbose bool
+}
+
+// ServeHTTP implements http.Handler
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rep := h.reg.Report()
+	if len(h.tags) != 0 {
+		rep = rep.Filter(h.tags...)
+	}
+
+	code := http.StatusOK
+	if !rep.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if !h.verbose && r.URL.Query()["verbose"] == nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(rep)
+}
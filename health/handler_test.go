@@ -0,0 +1,77 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var reg *Registry
+	var db, proc bool
+
+	serve := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", target, nil)
+		g.Expect(err).NotTo(g.HaveOccurred())
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	BeforeEach(func() {
+		db, proc = true, true
+
+		reg = NewRegistry()
+		g.Expect(reg.Register("db", CheckFunc(func() bool { return db }), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register("proc", CheckFunc(func() bool { return proc }), &CheckOptions{Critical: true, Tags: []string{TagLiveness}})).To(g.Succeed())
+	})
+
+	It("should serve readiness", func() {
+		h := NewReadinessHandler(reg)
+
+		w := serve(h, "/ready")
+		g.Expect(w.Code).To(g.Equal(http.StatusOK))
+		g.Expect(w.Body.Len()).To(g.BeZero())
+
+		db = false
+		w = serve(h, "/ready")
+		g.Expect(w.Code).To(g.Equal(http.StatusServiceUnavailable))
+		g.Expect(w.Body.Len()).To(g.BeZero())
+	})
+
+	It("should serve liveness", func() {
+		h := NewLivenessHandler(reg)
+
+		db = false
+		g.Expect(serve(h, "/live").Code).To(g.Equal(http.StatusOK))
+
+		proc = false
+		g.Expect(serve(h, "/live").Code).To(g.Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should serve verbose responses", func() {
+		db = false
+
+		w := serve(NewReadinessHandler(reg), "/ready?verbose")
+		g.Expect(w.Code).To(g.Equal(http.StatusServiceUnavailable))
+		g.Expect(w.Header().Get("Content-Type")).To(g.Equal("application/json; charset=utf-8"))
+
+		var rep Report
+		g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(g.Succeed())
+		g.Expect(rep.Healthy).To(g.BeFalse())
+		g.Expect(rep.Checks).To(g.HaveLen(2))
+		g.Expect(rep.Checks[0].Name).To(g.Equal("db"))
+		g.Expect(rep.Checks[0].Healthy).To(g.BeFalse())
+		g.Expect(rep.Checks[0].Since).NotTo(g.BeZero())
+
+		w = serve(NewHandler(reg, &HandlerOptions{Verbose: true, Tags: []string{TagLiveness}}), "/live")
+		g.Expect(w.Code).To(g.Equal(http.StatusOK))
+		g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(g.Succeed())
+		g.Expect(rep.Healthy).To(g.BeTrue())
+		g.Expect(rep.Checks).To(g.HaveLen(1))
+	})
+
+})
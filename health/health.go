@@ -1,5 +1,7 @@
 package health
 
+import "fmt"
+
 // Objects implementing the Check can be registered
 // with the env to indicate overall health status.
 //
@@ -18,3 +20,80 @@ type Check interface {
 type CheckFunc func() bool
 
 func (f CheckFunc) IsHealthy() bool { return f() }
+
+// Status is a health status level
+type Status uint8
+
+// Available status levels, from worst to best
+const (
+	StatusUnhealthy Status = iota
+	StatusDegraded
+	StatusHealthy
+)
+
+// String returns the status name
+func (s Status) String() string {
+	switch s {
+	case StatusUnhealthy:
+		return "unhealthy"
+	case StatusDegraded:
+		return "degraded"
+	case StatusHealthy:
+		return "healthy"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Status) UnmarshalText(data []byte) error {
+	switch string(data) {
+	case "unhealthy":
+		*s = StatusUnhealthy
+	case "degraded":
+		*s = StatusDegraded
+	case "healthy":
+		*s = StatusHealthy
+	default:
+		return fmt.Errorf("health: unknown status %q", data)
+	}
+	return nil
+}
+
+// StatusChecker is a Check which can report a more detailed status
+// along with a human-readable reason.
+//
+// Like IsHealthy, CheckStatus must return as quickly as possible.
+// Implementations must ensure that IsHealthy returns false if, and only if,
+// CheckStatus returns StatusUnhealthy. Degraded checks are therefore still
+// considered as healthy.
+type StatusChecker interface {
+	Check
+	CheckStatus() (Status, string)
+}
+
+// StatusOf returns the status of any check. Checks not implementing the
+// StatusChecker interface can only be either healthy or unhealthy.
+func StatusOf(c Check) (Status, string) {
+	if sc, ok := c.(StatusChecker); ok {
+		return sc.CheckStatus()
+	}
+	if c.IsHealthy() {
+		return StatusHealthy, ""
+	}
+	return StatusUnhealthy, ""
+}
+
+// StatusCheckFunc can be registered as a StatusChecker
+type StatusCheckFunc func() (Status, string)
+
+func (f StatusCheckFunc) IsHealthy() bool {
+	s, _ := f()
+	return s != StatusUnhealthy
+}
+
+func (f StatusCheckFunc) CheckStatus() (Status, string) { return f() }
@@ -1,13 +1,49 @@
 package health
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
-	. "github.com/onsi/gomega"
+	g "github.com/onsi/gomega"
 )
 
 func TestSuite(t *testing.T) {
-	RegisterFailHandler(Fail)
+	g.RegisterFailHandler(Fail)
 	RunSpecs(t, "flood/health")
 }
+
+var _ = Describe("Status", func() {
+
+	It("should have names", func() {
+		g.Expect(StatusUnhealthy.String()).To(g.Equal("unhealthy"))
+		g.Expect(StatusDegraded.String()).To(g.Equal("degraded"))
+		g.Expect(StatusHealthy.String()).To(g.Equal("healthy"))
+		g.Expect(Status(9).String()).To(g.Equal("unknown"))
+	})
+
+	It("should encode/decode JSON", func() {
+		data, err := json.Marshal([]Status{StatusDegraded, StatusHealthy})
+		g.Expect(err).NotTo(g.HaveOccurred())
+		g.Expect(string(data)).To(g.Equal(`["degraded","healthy"]`))
+
+		var statuses []Status
+		g.Expect(json.Unmarshal(data, &statuses)).To(g.Succeed())
+		g.Expect(statuses).To(g.Equal([]Status{StatusDegraded, StatusHealthy}))
+		g.Expect(json.Unmarshal([]byte(`["bad"]`), &statuses)).To(g.MatchError(`health: unknown status "bad"`))
+	})
+
+	It("should adapt checks", func() {
+		status, _ := StatusOf(CheckFunc(func() bool { return true }))
+		g.Expect(status).To(g.Equal(StatusHealthy))
+		status, _ = StatusOf(CheckFunc(func() bool { return false }))
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+
+		check := StatusCheckFunc(func() (Status, string) { return StatusDegraded, "slow" })
+		g.Expect(check.IsHealthy()).To(g.BeTrue())
+		status, reason := StatusOf(check)
+		g.Expect(status).To(g.Equal(StatusDegraded))
+		g.Expect(reason).To(g.Equal("slow"))
+	})
+
+})
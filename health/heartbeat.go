@@ -0,0 +1,155 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+// Heartbeat is a check for background workers. Workers call Beat on each
+// iteration and the check reports unhealthy if no beat was received within
+// the max age. IsHealthy never blocks.
+type Heartbeat struct {
+	maxAge time.Duration
+	last   int64 // unix nanos
+	now    func() time.Time
+}
+
+// NewHeartbeat creates a new heartbeat check. The time of creation counts
+// as the first beat.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{maxAge: maxAge, now: time.Now}
+	h.Beat()
+	return h
+}
+
+// Beat records a heartbeat
+func (h *Heartbeat) Beat() {
+	h.beatAt(h.now())
+}
+
+// Last returns the time of the last beat
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&h.last))
+}
+
+// Age returns the time elapsed since the last beat
+func (h *Heartbeat) Age() time.Duration {
+	return h.now().Sub(h.Last())
+}
+
+// IsHealthy implements Check interface
+func (h *Heartbeat) IsHealthy() bool {
+	return h.Age() <= h.maxAge
+}
+
+// CheckStatus implements StatusChecker interface
+func (h *Heartbeat) CheckStatus() (Status, string) {
+	if age := h.Age(); age > h.maxAge {
+		return StatusUnhealthy, fmt.Sprintf("last beat %s ago exceeds max age of %s",
+			age-age%time.Millisecond, h.maxAge)
+	}
+	return StatusHealthy, ""
+}
+
+func (h *Heartbeat) beatAt(t time.Time) {
+	atomic.StoreInt64(&h.last, t.UnixNano())
+}
+
+// --------------------------------------------------------------------
+
+// FileHeartbeat is a heartbeat check which watches the modification time
+// of a file, e.g. a data feed which is periodically rewritten by another
+// process. The file is polled in the background and IsHealthy never blocks.
+type FileHeartbeat struct {
+	beat *Heartbeat
+	path string
+
+	failed  int32
+	lastErr error
+	mu      sync.Mutex
+
+	closer tomb.Tomb
+}
+
+// NewFileHeartbeat creates a check which stats the file at path every inter
+// and is healthy as long as its modification time is not older than maxAge.
+// A failed stat, e.g. a missing file, marks the check as unhealthy. The first
+// stat is performed immediately.
+func NewFileHeartbeat(path string, maxAge, inter time.Duration) *FileHeartbeat {
+	h := &FileHeartbeat{
+		beat: &Heartbeat{maxAge: maxAge, now: time.Now},
+		path: path,
+	}
+	h.update()
+	h.closer.Go(func() error { return h.loop(inter) })
+	return h
+}
+
+// Last returns the last observed modification time
+func (h *FileHeartbeat) Last() time.Time {
+	return h.beat.Last()
+}
+
+// Age returns the time elapsed since the last observed modification
+func (h *FileHeartbeat) Age() time.Duration {
+	return h.beat.Age()
+}
+
+// IsHealthy implements Check interface
+func (h *FileHeartbeat) IsHealthy() bool {
+	return atomic.LoadInt32(&h.failed) == 0 && h.beat.IsHealthy()
+}
+
+// CheckStatus implements StatusChecker interface
+func (h *FileHeartbeat) CheckStatus() (Status, string) {
+	h.mu.Lock()
+	err := h.lastErr
+	h.mu.Unlock()
+
+	if err != nil {
+		return StatusUnhealthy, err.Error()
+	}
+	return h.beat.CheckStatus()
+}
+
+// Stop stops polling
+func (h *FileHeartbeat) Stop() {
+	h.closer.Kill(nil)
+	h.closer.Wait()
+}
+
+func (h *FileHeartbeat) loop(inter time.Duration) error {
+	ticker := time.NewTicker(inter)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.closer.Dying():
+			return nil
+		case <-ticker.C:
+			h.update()
+		}
+	}
+}
+
+func (h *FileHeartbeat) update() {
+	fi, err := os.Stat(h.path)
+	if err == nil {
+		h.beat.beatAt(fi.ModTime())
+	}
+
+	h.mu.Lock()
+	h.lastErr = err
+	h.mu.Unlock()
+
+	var failed int32
+	if err != nil {
+		failed = 1
+	}
+	atomic.StoreInt32(&h.failed, failed)
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Heartbeat", func() {
+	var subject *Heartbeat
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Unix(1500000000, 0)
+		subject = NewHeartbeat(time.Second)
+		subject.now = func() time.Time { return now }
+		subject.Beat()
+	})
+
+	It("should be healthy on creation", func() {
+		g.Expect(NewHeartbeat(time.Second).IsHealthy()).To(g.BeTrue())
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		g.Expect(subject.Last()).To(g.Equal(now))
+	})
+
+	It("should become unhealthy when beats stop", func() {
+		now = now.Add(time.Second)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		now = now.Add(1500 * time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		g.Expect(subject.Age()).To(g.Equal(2500 * time.Millisecond))
+
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("last beat 2.5s ago exceeds max age of 1s"))
+
+		subject.Beat()
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		g.Expect(subject.Last()).To(g.Equal(now))
+	})
+
+})
+
+var _ = Describe("FileHeartbeat", func() {
+	var dir, path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "health-heartbeat")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		path = filepath.Join(dir, "feed")
+		g.Expect(ioutil.WriteFile(path, []byte("x"), 0644)).To(g.Succeed())
+	})
+
+	AfterEach(func() {
+		g.Expect(os.RemoveAll(dir)).To(g.Succeed())
+	})
+
+	It("should check file modification times", func() {
+		subject := NewFileHeartbeat(path, time.Minute, time.Hour)
+		defer subject.Stop()
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		old := time.Now().Add(-time.Hour)
+		g.Expect(os.Chtimes(path, old, old)).To(g.Succeed())
+		subject.update()
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		g.Expect(subject.Last().Unix()).To(g.Equal(old.Unix()))
+
+		status, _ := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+	})
+
+	It("should fail on missing files", func() {
+		subject := NewFileHeartbeat(filepath.Join(dir, "missing"), time.Minute, time.Hour)
+		defer subject.Stop()
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		_, reason := subject.CheckStatus()
+		g.Expect(reason).To(g.ContainSubstring("no such file or directory"))
+	})
+
+	It("should poll periodically", func() {
+		old := time.Now().Add(-time.Hour)
+		g.Expect(os.Chtimes(path, old, old)).To(g.Succeed())
+
+		subject := NewFileHeartbeat(path, time.Minute, time.Millisecond)
+		defer subject.Stop()
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		now := time.Now()
+		g.Expect(os.Chtimes(path, now, now)).To(g.Succeed())
+		g.Eventually(subject.IsHealthy, "50ms", "2ms").Should(g.BeTrue())
+	})
+
+})
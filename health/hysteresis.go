@@ -0,0 +1,61 @@
+package health
+
+import "sync/atomic"
+
+// hysteresis tracks a healthy state which only changes after a number of
+// subsequent successes (rise) or failures (fall).
+type hysteresis struct {
+	rise, fall int
+
+	successes, fails, healthy int32
+}
+
+func newHysteresis(rise, fall int, healthy bool) hysteresis {
+	h := hysteresis{rise: rise, fall: fall}
+	if healthy {
+		h.healthy = 1
+		h.successes = int32(rise)
+	}
+	return h
+}
+
+// IsHealthy returns the current state
+func (h *hysteresis) IsHealthy() bool {
+	return atomic.LoadInt32(&h.healthy) > 0
+}
+
+// Counts returns the numbers of subsequent successes and failures
+func (h *hysteresis) Counts() (int, int) {
+	return int(atomic.LoadInt32(&h.successes)), int(atomic.LoadInt32(&h.fails))
+}
+
+// Update records an outcome and returns true if the state has changed as a result
+func (h *hysteresis) Update(ok bool) bool {
+	if ok {
+		atomic.StoreInt32(&h.fails, 0)
+
+		n := int(atomic.AddInt32(&h.successes, 1))
+		if n > h.rise {
+			atomic.AddInt32(&h.successes, -1)
+		} else if n == h.rise {
+			return atomic.CompareAndSwapInt32(&h.healthy, 0, 1)
+		}
+	} else {
+		atomic.StoreInt32(&h.successes, 0)
+
+		if n := int(atomic.AddInt32(&h.fails, 1)); n > h.fall {
+			atomic.AddInt32(&h.fails, -1)
+		} else if n == h.fall {
+			return atomic.CompareAndSwapInt32(&h.healthy, 1, 0)
+		}
+	}
+	return false
+}
+
+// Set forces the state and returns true if it has changed as a result
+func (h *hysteresis) Set(healthy bool) bool {
+	if healthy {
+		return atomic.CompareAndSwapInt32(&h.healthy, 0, 1)
+	}
+	return atomic.CompareAndSwapInt32(&h.healthy, 1, 0)
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"bufio"
+	"expvar"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// latencyBuckets are the upper bounds of ping latency histograms, in seconds
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// WriteMetrics writes the state of all registered checks to w, using the
+// Prometheus text exposition format. The following metrics are exported:
+//
+//	health_check_up                  gauge, 1 if the check is healthy, 0 otherwise
+//	health_check_status              gauge, 0 = unhealthy, 1 = degraded, 2 = healthy
+//	health_check_transitions_total   counter, the number of observed status transitions
+//	health_ping_latency_seconds      histogram of ping latencies, Ping checks only
+//
+// All metrics are labelled with the check name.
+func (r *Registry) WriteMetrics(w io.Writer) error {
+	rep := r.Report()
+
+	pings := make(map[string]*histogram)
+	for _, e := range r.snapshot() {
+		if p, ok := e.check.(*Ping); ok {
+			pings[e.name] = p.latencyHistogram()
+		}
+	}
+
+	b := bufio.NewWriter(w)
+
+	writeMetricHeader(b, "health_check_up", "gauge", "Whether the check is healthy (1) or not (0).")
+	for _, e := range rep.Checks {
+		up := 0.0
+		if e.Healthy {
+			up = 1
+		}
+		writeMetric(b, "health_check_up", e.Name, "", up)
+	}
+
+	writeMetricHeader(b, "health_check_status", "gauge", "The check status (0 = unhealthy, 1 = degraded, 2 = healthy).")
+	for _, e := range rep.Checks {
+		writeMetric(b, "health_check_status", e.Name, "", float64(e.Status))
+	}
+
+	writeMetricHeader(b, "health_check_transitions_total", "counter", "The number of observed check status transitions.")
+	for _, e := range rep.Checks {
+		writeMetric(b, "health_check_transitions_total", e.Name, "", float64(e.Transitions))
+	}
+
+	if len(pings) != 0 {
+		names := make([]string, 0, len(pings))
+		for name := range pings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		writeMetricHeader(b, "health_ping_latency_seconds", "histogram", "The ping latency in seconds.")
+		for _, name := range names {
+			h := pings[name]
+
+			var cum uint64
+			for i, le := range h.bounds {
+				cum += h.counts[i]
+				writeMetric(b, "health_ping_latency_seconds_bucket", name, formatMetricValue(le), float64(cum))
+			}
+			writeMetric(b, "health_ping_latency_seconds_bucket", name, "+Inf", float64(h.count))
+			writeMetric(b, "health_ping_latency_seconds_sum", name, "", h.sum)
+			writeMetric(b, "health_ping_latency_seconds_count", name, "", float64(h.count))
+		}
+	}
+
+	return b.Flush()
+}
+
+// Expvar returns the state of all registered checks as an expvar.Var, e.g.:
+//
+//	expvar.Publish("health", registry.Expvar())
+func (r *Registry) Expvar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		rep := r.Report()
+		res := make(map[string]interface{}, len(rep.Checks))
+		for _, e := range rep.Checks {
+			res[e.Name] = map[string]interface{}{
+				"healthy":     e.Healthy,
+				"status":      e.Status.String(),
+				"transitions": e.Transitions,
+			}
+		}
+		return res
+	})
+}
+
+// NewMetricsHandler creates a HTTP handler which serves registry metrics
+// in the Prometheus text exposition format.
+func NewMetricsHandler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = reg.WriteMetrics(w)
+	})
+}
+
+// --------------------------------------------------------------------
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeMetricHeader(b *bufio.Writer, name, typ, help string) {
+	b.WriteString("# HELP " + name + " " + help + "\n")
+	b.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeMetric(b *bufio.Writer, name, check, le string, value float64) {
+	b.WriteString(name)
+	b.WriteString(`{name="`)
+	b.WriteString(metricLabelEscaper.Replace(check))
+	if le != "" {
+		b.WriteString(`",le="`)
+		b.WriteString(le)
+	}
+	b.WriteString(`"} `)
+	b.WriteString(formatMetricValue(value))
+	b.WriteByte('\n')
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// --------------------------------------------------------------------
+
+// histogram is a cumulative histogram, it is not thread-safe
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+// Observe adds a single observation
+func (h *histogram) Observe(v float64) {
+	h.count++
+	h.sum += v
+
+	if i := sort.SearchFloat64s(h.bounds, v); i < len(h.counts) {
+		h.counts[i]++
+	}
+}
+
+// Copy returns a copy of the histogram
+func (h *histogram) Copy() *histogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return &c
+}
@@ -0,0 +1,92 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var reg *Registry
+	var ping *Ping
+	var db bool
+
+	BeforeEach(func() {
+		db = true
+
+		ping = NewPing(func() error { return nil }, time.Hour, 1, 1)
+		ping.record(nil, time.Now(), 3*time.Millisecond)
+		ping.record(nil, time.Now(), 20*time.Millisecond)
+		ping.record(nil, time.Now(), 20*time.Second)
+
+		reg = NewRegistry()
+		g.Expect(reg.Register("db", CheckFunc(func() bool { return db }), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register(`redis "main"`, ping, nil)).To(g.Succeed())
+	})
+
+	AfterEach(func() {
+		ping.Stop()
+	})
+
+	It("should write metrics", func() {
+		db = false
+		reg.Report()
+		ping.update(nil)
+
+		buf := new(bytes.Buffer)
+		g.Expect(reg.WriteMetrics(buf)).To(g.Succeed())
+		g.Expect(buf.String()).To(g.Equal(`# HELP health_check_up Whether the check is healthy (1) or not (0).
+# TYPE health_check_up gauge
+health_check_up{name="db"} 0
+health_check_up{name="redis \"main\""} 1
+# HELP health_check_status The check status (0 = unhealthy, 1 = degraded, 2 = healthy).
+# TYPE health_check_status gauge
+health_check_status{name="db"} 0
+health_check_status{name="redis \"main\""} 2
+# HELP health_check_transitions_total The number of observed check status transitions.
+# TYPE health_check_transitions_total counter
+health_check_transitions_total{name="db"} 1
+health_check_transitions_total{name="redis \"main\""} 1
+# HELP health_ping_latency_seconds The ping latency in seconds.
+# TYPE health_ping_latency_seconds histogram
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.001"} 0
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.005"} 1
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.01"} 1
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.025"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.05"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.1"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.25"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="0.5"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="1"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="2.5"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="5"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="10"} 2
+health_ping_latency_seconds_bucket{name="redis \"main\"",le="+Inf"} 3
+health_ping_latency_seconds_sum{name="redis \"main\""} 20.023
+health_ping_latency_seconds_count{name="redis \"main\""} 3
+`))
+	})
+
+	It("should serve metrics", func() {
+		w := httptest.NewRecorder()
+		NewMetricsHandler(reg).ServeHTTP(w, nil)
+		g.Expect(w.Code).To(g.Equal(http.StatusOK))
+		g.Expect(w.Header().Get("Content-Type")).To(g.Equal("text/plain; version=0.0.4; charset=utf-8"))
+		g.Expect(w.Body.String()).To(g.ContainSubstring(`health_check_up{name="db"} 1`))
+	})
+
+	It("should export expvars", func() {
+		var vars map[string]map[string]interface{}
+		g.Expect(json.Unmarshal([]byte(reg.Expvar().String()), &vars)).To(g.Succeed())
+		g.Expect(vars).To(g.Equal(map[string]map[string]interface{}{
+			"db":           {"healthy": true, "status": "healthy", "transitions": 0.0},
+			`redis "main"`: {"healthy": false, "status": "unhealthy", "transitions": 0.0},
+		}))
+	})
+
+})
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MiddlewareOptions contain optional middleware settings
+type MiddlewareOptions struct {
+	// StatusCode is the response code for rejected requests,
+	// e.g. http.StatusNoContent for no-bid responses.
+	// Default: http.StatusServiceUnavailable
+	StatusCode int
+	// RetryAfter adds a Retry-After header to rejected requests,
+	// rounded up to full seconds. Default: 0 (no header)
+	RetryAfter time.Duration
+	// DegradedShed is the fraction of requests to reject while the check
+	// is degraded. Values outside of (0, 1] are ignored.
+	// Default: 1 (reject all)
+	DegradedShed float64
+	// AcceptDegraded accepts all requests while the check is degraded,
+	// e.g. to keep bidding while only non-critical checks are failing.
+	// Overrides DegradedShed. Default: false
+	AcceptDegraded bool
+}
+
+func (o *MiddlewareOptions) norm() *MiddlewareOptions {
+	var oo MiddlewareOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.StatusCode == 0 {
+		oo.StatusCode = http.StatusServiceUnavailable
+	}
+	if oo.AcceptDegraded {
+		oo.DegradedShed = 0
+	} else if oo.DegradedShed <= 0 || oo.DegradedShed > 1 {
+		oo.DegradedShed = 1
+	}
+	return &oo
+}
+
+// Middleware wraps HTTP handlers and sheds load based on the state of the check.
+// While the check is unhealthy, all requests are rejected with a quick empty
+// response. While the check is degraded, requests are rejected too, unless
+// configured to shed only a fraction or to accept degraded traffic, see
+// MiddlewareOptions. Options are optional and may be nil.
+func Middleware(check Check, opts *MiddlewareOptions) func(http.Handler) http.Handler {
+	opts = opts.norm()
+
+	var retryAfter string
+	if opts.RetryAfter > 0 {
+		secs := (opts.RetryAfter + time.Second - 1) / time.Second
+		retryAfter = strconv.FormatInt(int64(secs), 10)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &middleware{
+			next:       next,
+			check:      check,
+			code:       opts.StatusCode,
+			retryAfter: retryAfter,
+			shed:       opts.DegradedShed,
+		}
+	}
+}
+
+type middleware struct {
+	next  http.Handler
+	check Check
+
+	code       int
+	retryAfter string
+	shed       float64
+}
+
+// ServeHTTP implements http.Handler
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !m.reject() {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	if m.retryAfter != "" {
+		w.Header().Set("Retry-After", m.retryAfter)
+	}
+	w.WriteHeader(m.code)
+}
+
+func (m *middleware) reject() bool {
+	if !m.check.IsHealthy() {
+		return true
+	}
+	if m.shed <= 0 {
+		return false
+	}
+
+	// only inspect status unless degraded traffic is accepted,
+	// as it may be considerably more expensive than IsHealthy
+	if status, _ := StatusOf(m.check); status != StatusDegraded {
+		return false
+	}
+	return m.shed >= 1 || rand.Float64() < m.shed
+}
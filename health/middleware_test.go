@@ -0,0 +1,104 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	var status Status
+
+	check := StatusCheckFunc(func() (Status, string) { return status, "" })
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(h http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/bid", nil)
+		g.Expect(err).NotTo(g.HaveOccurred())
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	BeforeEach(func() {
+		status = StatusHealthy
+	})
+
+	It("should reject requests while unhealthy", func() {
+		h := Middleware(check, nil)(next)
+		g.Expect(serve(h).Code).To(g.Equal(http.StatusOK))
+
+		status = StatusUnhealthy
+		w := serve(h)
+		g.Expect(w.Code).To(g.Equal(http.StatusServiceUnavailable))
+		g.Expect(w.Header()).NotTo(g.HaveKey("Retry-After"))
+
+		status = StatusDegraded
+		g.Expect(serve(h).Code).To(g.Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should accept degraded traffic if configured", func() {
+		h := Middleware(check, &MiddlewareOptions{AcceptDegraded: true, DegradedShed: 0.5})(next)
+
+		status = StatusDegraded
+		for i := 0; i < 100; i++ {
+			g.Expect(serve(h).Code).To(g.Equal(http.StatusOK))
+		}
+
+		status = StatusUnhealthy
+		g.Expect(serve(h).Code).To(g.Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should shed degraded registries", func() {
+		reg := NewRegistry()
+		g.Expect(reg.Register("db", CheckFunc(func() bool { return true }), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register("cache", CheckFunc(func() bool { return false }), nil)).To(g.Succeed())
+
+		h := Middleware(reg, nil)(next)
+		g.Expect(serve(h).Code).To(g.Equal(http.StatusServiceUnavailable))
+
+		h = Middleware(reg, &MiddlewareOptions{AcceptDegraded: true})(next)
+		g.Expect(serve(h).Code).To(g.Equal(http.StatusOK))
+	})
+
+	It("should support custom codes and Retry-After", func() {
+		h := Middleware(check, &MiddlewareOptions{
+			StatusCode: http.StatusNoContent,
+			RetryAfter: 1500 * time.Millisecond,
+		})(next)
+
+		status = StatusUnhealthy
+		w := serve(h)
+		g.Expect(w.Code).To(g.Equal(http.StatusNoContent))
+		g.Expect(w.Header().Get("Retry-After")).To(g.Equal("2"))
+	})
+
+	DescribeTable("should shed a fraction while degraded",
+		func(shed float64, min, max int) {
+			h := Middleware(check, &MiddlewareOptions{DegradedShed: shed})(next)
+			status = StatusDegraded
+
+			n := 0
+			for i := 0; i < 1000; i++ {
+				if serve(h).Code != http.StatusOK {
+					n++
+				}
+			}
+			g.Expect(n).To(g.BeNumerically(">=", min))
+			g.Expect(n).To(g.BeNumerically("<=", max))
+		},
+
+		Entry("all", 1.0, 1000, 1000),
+		Entry("default", 0.0, 1000, 1000),
+		Entry("negative", -1.0, 1000, 1000),
+		Entry("half", 0.5, 400, 600),
+		Entry("tenth", 0.1, 50, 150),
+	)
+
+})
@@ -0,0 +1,248 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+var errNotifyQueueFull = errors.New("health: notification queue full, event dropped")
+
+// Event describes a change of health status
+type Event struct {
+	// Name is the name of the check, empty for the aggregate registry status
+	Name   string    `json:"name"`
+	Status Status    `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Sink receives notifications, see WebhookSink, LogSink and ExecSink.
+// The context is cancelled when the notifier is stopped.
+type Sink interface {
+	Notify(context.Context, Event) error
+}
+
+// SinkFunc can be used as a Sink
+type SinkFunc func(context.Context, Event) error
+
+// Notify implements Sink interface
+func (f SinkFunc) Notify(ctx context.Context, ev Event) error { return f(ctx, ev) }
+
+// NotifierOptions contain optional notifier settings
+type NotifierOptions struct {
+	// Interval at which the registry is polled for changes. Default: 1s
+	Interval time.Duration
+	// Debounce is the minimum time a new status must persist
+	// before a notification is sent. Default: 0
+	Debounce time.Duration
+	// RateLimit limits the number of notifications per check
+	// within RatePeriod. Suppressed changes are delivered as soon as
+	// the limit permits, unless they are reverted in the meantime.
+	// Default: 0 (unlimited)
+	RateLimit int
+	// RatePeriod is the period of RateLimit. Default: 1m
+	RatePeriod time.Duration
+	// QueueSize is the number of events that can be buffered
+	// per sink. Default: 100
+	QueueSize int
+	// OnError is called when a sink fails to process an event.
+	// Default: errors are ignored
+	OnError func(Event, error)
+}
+
+func (o *NotifierOptions) norm() *NotifierOptions {
+	var oo NotifierOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.Interval <= 0 {
+		oo.Interval = time.Second
+	}
+	if oo.RatePeriod <= 0 {
+		oo.RatePeriod = time.Minute
+	}
+	if oo.QueueSize < 1 {
+		oo.QueueSize = 100
+	}
+	if oo.OnError == nil {
+		oo.OnError = func(Event, error) {}
+	}
+	return &oo
+}
+
+// Notifier watches a registry and notifies sinks when the
+// status of individual checks or the aggregate status changes.
+type Notifier struct {
+	reg    *Registry
+	opts   *NotifierOptions
+	queues []chan Event
+	states map[string]*notifyState
+	now    func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closer tomb.Tomb
+}
+
+// NewNotifier creates and starts a new notifier. All checks are
+// assumed to be healthy initially, i.e. checks which are unhealthy
+// on start will trigger a notification. Options are optional and may be nil.
+func NewNotifier(reg *Registry, opts *NotifierOptions, sinks ...Sink) *Notifier {
+	opts = opts.norm()
+	n := &Notifier{
+		reg:    reg,
+		opts:   opts,
+		queues: make([]chan Event, len(sinks)),
+		states: make(map[string]*notifyState),
+		now:    time.Now,
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+
+	for i, sink := range sinks {
+		sink, queue := sink, make(chan Event, opts.QueueSize)
+		n.queues[i] = queue
+		n.closer.Go(func() error { return n.deliver(sink, queue) })
+	}
+	n.closer.Go(n.loop)
+	return n
+}
+
+// Stop stops the notifier. Queued events are discarded and
+// pending deliveries are cancelled.
+func (n *Notifier) Stop() {
+	n.cancel()
+	n.closer.Kill(nil)
+	n.closer.Wait()
+}
+
+func (n *Notifier) loop() error {
+	ticker := time.NewTicker(n.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		n.poll()
+
+		select {
+		case <-n.closer.Dying():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Notifier) deliver(sink Sink, queue <-chan Event) error {
+	for {
+		select {
+		case <-n.closer.Dying():
+			return nil
+		case ev := <-queue:
+			if err := sink.Notify(n.ctx, ev); err != nil {
+				n.opts.OnError(ev, err)
+			}
+		}
+	}
+}
+
+// poll compares the current report against the last
+// notified state and emits events
+func (n *Notifier) poll() {
+	now := n.now()
+	rep := n.reg.Report()
+
+	seen := make(map[string]bool, len(rep.Checks)+1)
+	reasons := make([]string, 0, len(rep.Checks))
+	for _, e := range rep.Checks {
+		seen[e.Name] = true
+		if e.Status != StatusHealthy {
+			reasons = append(reasons, e.Name+" is "+e.Status.String())
+		}
+		n.observe(now, e.Name, e.Status, e.Reason)
+	}
+
+	seen[""] = true
+	n.observe(now, "", rep.Status, strings.Join(reasons, ", "))
+
+	for name := range n.states {
+		if !seen[name] {
+			delete(n.states, name)
+		}
+	}
+}
+
+func (n *Notifier) observe(now time.Time, name string, status Status, reason string) {
+	st, ok := n.states[name]
+	if !ok {
+		st = &notifyState{status: StatusHealthy}
+		n.states[name] = st
+	}
+
+	if status == st.status {
+		st.pending = false
+		return
+	}
+	if !st.pending || status != st.pendingStatus {
+		st.pending = true
+		st.pendingStatus = status
+		st.pendingSince = now
+	}
+	if now.Sub(st.pendingSince) < n.opts.Debounce {
+		return
+	}
+	if !st.allow(now, n.opts.RateLimit, n.opts.RatePeriod) {
+		return
+	}
+
+	st.status = status
+	st.pending = false
+	n.emit(Event{Name: name, Status: status, Reason: reason, Time: now})
+}
+
+func (n *Notifier) emit(ev Event) {
+	for _, queue := range n.queues {
+		select {
+		case queue <- ev:
+		default:
+			n.opts.OnError(ev, errNotifyQueueFull)
+		}
+	}
+}
+
+// --------------------------------------------------------------------
+
+type notifyState struct {
+	status Status
+
+	pending       bool
+	pendingStatus Status
+	pendingSince  time.Time
+
+	sent []time.Time
+}
+
+// allow applies the rate limit and records the notification if permitted
+func (s *notifyState) allow(now time.Time, limit int, period time.Duration) bool {
+	if limit < 1 {
+		return true
+	}
+
+	cutoff := now.Add(-period)
+	n := 0
+	for _, t := range s.sent {
+		if t.After(cutoff) {
+			s.sent[n] = t
+			n++
+		}
+	}
+	s.sent = s.sent[:n]
+
+	if len(s.sent) >= limit {
+		return false
+	}
+	s.sent = append(s.sent, now)
+	return true
+}
@@ -0,0 +1,194 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notifier", func() {
+	var reg *Registry
+	var db, cache Status
+	var now time.Time
+	var queue chan Event
+
+	newSubject := func(opts *NotifierOptions) *Notifier {
+		queue = make(chan Event, 100)
+		return &Notifier{
+			reg:    reg,
+			opts:   opts.norm(),
+			queues: []chan Event{queue},
+			states: make(map[string]*notifyState),
+			now:    func() time.Time { return now },
+		}
+	}
+
+	drain := func() []Event {
+		var events []Event
+		for {
+			select {
+			case ev := <-queue:
+				events = append(events, ev)
+			default:
+				return events
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		db, cache = StatusHealthy, StatusHealthy
+		now = time.Unix(1500000000, 0)
+
+		reg = NewRegistry()
+		g.Expect(reg.Register("db", StatusCheckFunc(func() (Status, string) { return db, "" }), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register("cache", StatusCheckFunc(func() (Status, string) { return cache, "" }), nil)).To(g.Succeed())
+	})
+
+	It("should notify on changes", func() {
+		subject := newSubject(nil)
+		subject.poll()
+		g.Expect(drain()).To(g.BeEmpty())
+
+		cache = StatusUnhealthy
+		subject.poll()
+		g.Expect(drain()).To(g.Equal([]Event{
+			{Name: "cache", Status: StatusUnhealthy, Time: now},
+			{Name: "", Status: StatusDegraded, Reason: "cache is unhealthy", Time: now},
+		}))
+
+		subject.poll()
+		g.Expect(drain()).To(g.BeEmpty())
+
+		db = StatusUnhealthy
+		subject.poll()
+		g.Expect(drain()).To(g.Equal([]Event{
+			{Name: "db", Status: StatusUnhealthy, Time: now},
+			{Name: "", Status: StatusUnhealthy, Reason: "cache is unhealthy, db is unhealthy", Time: now},
+		}))
+
+		db, cache = StatusHealthy, StatusHealthy
+		subject.poll()
+		g.Expect(drain()).To(g.HaveLen(3))
+	})
+
+	It("should debounce", func() {
+		subject := newSubject(&NotifierOptions{Debounce: 3 * time.Second})
+
+		cache = StatusUnhealthy
+		subject.poll()
+		g.Expect(drain()).To(g.BeEmpty())
+
+		// flap back before debounce expires
+		now = now.Add(2 * time.Second)
+		cache = StatusHealthy
+		subject.poll()
+		g.Expect(drain()).To(g.BeEmpty())
+
+		cache = StatusUnhealthy
+		for i := 0; i < 3; i++ {
+			now = now.Add(time.Second)
+			subject.poll()
+			g.Expect(drain()).To(g.BeEmpty())
+		}
+
+		now = now.Add(time.Second)
+		subject.poll()
+		g.Expect(drain()).To(g.HaveLen(2))
+	})
+
+	It("should rate limit", func() {
+		subject := newSubject(&NotifierOptions{RateLimit: 2, RatePeriod: time.Minute})
+		names := func() []string {
+			var names []string
+			for _, ev := range drain() {
+				names = append(names, ev.Name+":"+ev.Status.String())
+			}
+			return names
+		}
+
+		db = StatusUnhealthy
+		subject.poll()
+		g.Expect(names()).To(g.Equal([]string{"db:unhealthy", ":unhealthy"}))
+
+		now = now.Add(time.Second)
+		db = StatusHealthy
+		subject.poll()
+		g.Expect(names()).To(g.Equal([]string{"db:healthy", ":healthy"}))
+
+		now = now.Add(time.Second)
+		db = StatusUnhealthy
+		subject.poll()
+		g.Expect(names()).To(g.BeEmpty())
+
+		// state persists, delivered once the limit permits
+		now = now.Add(time.Minute)
+		subject.poll()
+		g.Expect(names()).To(g.Equal([]string{"db:unhealthy", ":unhealthy"}))
+	})
+
+	It("should forget unregistered checks", func() {
+		subject := newSubject(nil)
+		cache = StatusUnhealthy
+		subject.poll()
+		g.Expect(subject.states).To(g.HaveKey("cache"))
+
+		reg.Unregister("cache")
+		subject.poll()
+		g.Expect(subject.states).NotTo(g.HaveKey("cache"))
+		g.Expect(drain()).To(g.HaveLen(3))
+	})
+
+	It("should deliver to sinks", func() {
+		var mu sync.Mutex
+		var events []Event
+		sink := SinkFunc(func(_ context.Context, ev Event) error {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+			return nil
+		})
+		count := func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(events)
+		}
+
+		var status int32
+		reg := NewRegistry()
+		g.Expect(reg.Register("db", StatusCheckFunc(func() (Status, string) {
+			return Status(atomic.LoadInt32(&status)), ""
+		}), nil)).To(g.Succeed())
+
+		subject := NewNotifier(reg, &NotifierOptions{Interval: time.Millisecond}, sink)
+		defer subject.Stop()
+		g.Eventually(count, "50ms", "2ms").Should(g.Equal(2))
+
+		atomic.StoreInt32(&status, int32(StatusHealthy))
+		g.Eventually(count, "50ms", "2ms").Should(g.Equal(4))
+	})
+
+	It("should cancel pending deliveries on stop", func() {
+		var started int32
+		sink := SinkFunc(func(ctx context.Context, _ Event) error {
+			atomic.StoreInt32(&started, 1)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		db = StatusUnhealthy
+		subject := NewNotifier(reg, &NotifierOptions{Interval: time.Millisecond}, sink)
+		g.Eventually(func() int32 { return atomic.LoadInt32(&started) }, "50ms", "2ms").Should(g.Equal(int32(1)))
+
+		done := make(chan struct{})
+		go func() {
+			subject.Stop()
+			close(done)
+		}()
+		g.Eventually(done, "100ms").Should(g.BeClosed())
+	})
+
+})
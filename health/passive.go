@@ -0,0 +1,213 @@
+package health
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PassiveOptions contain optional passive check settings
+type PassiveOptions struct {
+	// Window is the sliding time window across which outcomes are evaluated. Default: 10s
+	Window time.Duration
+	// Buckets is the number of buckets the window is divided into. The window is
+	// evaluated each time a bucket is completed. Default: 10
+	Buckets int
+	// MaxErrorRatio is the maximum acceptable ratio of errors within the window. Default: 0.5
+	MaxErrorRatio float64
+	// MaxLatency is the maximum acceptable mean latency within the window. Default: 0 (disabled)
+	MaxLatency time.Duration
+	// MinRequests is the minimum number of requests within the window that are required
+	// to fail an evaluation. Windows with fewer requests pass. Default: 10
+	MinRequests int
+	// Rise is the number of subsequent evaluations that must pass to be declared healthy. Default: 1
+	Rise int
+	// Fall is the number of subsequent evaluations that must fail to be declared unhealthy. Default: 1
+	Fall int
+}
+
+func (o *PassiveOptions) norm() *PassiveOptions {
+	var oo PassiveOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.Window <= 0 {
+		oo.Window = 10 * time.Second
+	}
+	if oo.Buckets < 1 {
+		oo.Buckets = 10
+	}
+	if oo.MaxErrorRatio <= 0 {
+		oo.MaxErrorRatio = 0.5
+	}
+	if oo.MinRequests < 1 {
+		oo.MinRequests = 10
+	}
+	if oo.Rise < 1 {
+		oo.Rise = 1
+	}
+	if oo.Fall < 1 {
+		oo.Fall = 1
+	}
+	return &oo
+}
+
+// Passive is a health check which is fed with the outcomes of real requests
+// by the application rather than by active pings. It keeps a sliding window
+// of error ratios and latencies and becomes unhealthy when either exceeds
+// its budget, using the same rise/fall hysteresis as Ping.
+//
+// A passive check starts healthy. Windows are also evaluated when the check
+// is read, so an unhealthy check recovers once traffic stops.
+type Passive struct {
+	opts  *PassiveOptions
+	width int64
+	now   func() time.Time
+
+	state   hysteresis
+	buckets []passiveBucket
+	lastIdx int64 // atomic
+	reason  string
+	mu      sync.Mutex
+}
+
+// NewPassive creates a new passive check. Options are optional and may be nil.
+func NewPassive(opts *PassiveOptions) *Passive {
+	opts = opts.norm()
+
+	width := int64(opts.Window) / int64(opts.Buckets)
+	if width < 1 {
+		width = 1
+	}
+
+	return &Passive{
+		opts:    opts,
+		width:   width,
+		now:     time.Now,
+		state:   newHysteresis(opts.Rise, opts.Fall, true),
+		buckets: make([]passiveBucket, opts.Buckets),
+	}
+}
+
+// IsHealthy implements Check interface
+func (p *Passive) IsHealthy() bool {
+	p.advance(p.now().UnixNano() / p.width)
+	return p.state.IsHealthy()
+}
+
+// CheckStatus implements StatusChecker interface
+func (p *Passive) CheckStatus() (Status, string) {
+	p.advance(p.now().UnixNano() / p.width)
+
+	p.mu.Lock()
+	reason := p.reason
+	p.mu.Unlock()
+
+	if !p.IsHealthy() {
+		return StatusUnhealthy, reason
+	}
+	if reason != "" {
+		return StatusDegraded, reason
+	}
+	return StatusHealthy, ""
+}
+
+// Record records the outcome of a request, a nil error is considered successful
+func (p *Passive) Record(err error, latency time.Duration) {
+	idx := p.now().UnixNano() / p.width
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.roll(idx)
+
+	b := &p.buckets[idx%int64(len(p.buckets))]
+	if b.idx != idx {
+		*b = passiveBucket{idx: idx}
+	}
+	b.requests++
+	b.latency += latency
+	if err != nil {
+		b.errors++
+	}
+}
+
+// Stats returns the number of requests, the error ratio and the mean latency
+// across the current window, including the incomplete current bucket.
+func (p *Passive) Stats() (int, float64, time.Duration) {
+	idx := p.now().UnixNano() / p.width
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stats(idx - int64(len(p.buckets)) + 1)
+}
+
+// advance rolls over to bucket idx, if it has not been reached yet
+func (p *Passive) advance(idx int64) {
+	if idx <= atomic.LoadInt64(&p.lastIdx) {
+		return
+	}
+
+	p.mu.Lock()
+	p.roll(idx)
+	p.mu.Unlock()
+}
+
+// roll evaluates the window when a new bucket is reached, must be called
+// with the lock held
+func (p *Passive) roll(idx int64) {
+	lastIdx := atomic.LoadInt64(&p.lastIdx)
+	if idx <= lastIdx {
+		return
+	}
+	if lastIdx != 0 {
+		p.evaluate(idx)
+	}
+	atomic.StoreInt64(&p.lastIdx, idx)
+}
+
+// evaluate evaluates all completed buckets within the window, must be called
+// with the lock held
+func (p *Passive) evaluate(idx int64) {
+	requests, errRatio, latency := p.stats(idx - int64(len(p.buckets)))
+
+	reason := ""
+	if requests >= p.opts.MinRequests {
+		if errRatio > p.opts.MaxErrorRatio {
+			reason = fmt.Sprintf("error ratio of %.2f exceeds %.2f", errRatio, p.opts.MaxErrorRatio)
+		} else if p.opts.MaxLatency > 0 && latency > p.opts.MaxLatency {
+			reason = fmt.Sprintf("mean latency of %s exceeds %s", latency, p.opts.MaxLatency)
+		}
+	}
+
+	p.reason = reason
+	p.state.Update(reason == "")
+}
+
+// stats sums up all buckets, starting at min, must be called with the lock held
+func (p *Passive) stats(min int64) (int, float64, time.Duration) {
+	var requests, errors int
+	var latency time.Duration
+	for _, b := range p.buckets {
+		if b.idx < min {
+			continue
+		}
+		requests += b.requests
+		errors += b.errors
+		latency += b.latency
+	}
+
+	if requests == 0 {
+		return 0, 0, 0
+	}
+	return requests, float64(errors) / float64(requests), latency / time.Duration(requests)
+}
+
+type passiveBucket struct {
+	idx              int64
+	requests, errors int
+	latency          time.Duration
+}
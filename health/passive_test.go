@@ -0,0 +1,152 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Passive", func() {
+	var subject *Passive
+	var now time.Time
+
+	record := func(n int, err error, latency time.Duration) {
+		for i := 0; i < n; i++ {
+			subject.Record(err, latency)
+		}
+	}
+
+	BeforeEach(func() {
+		now = time.Unix(1500000000, 0)
+		subject = NewPassive(&PassiveOptions{
+			Window:        4 * time.Second,
+			Buckets:       4,
+			MaxErrorRatio: 0.2,
+			MaxLatency:    100 * time.Millisecond,
+			MinRequests:   5,
+			Fall:          2,
+		})
+		subject.now = func() time.Time { return now }
+	})
+
+	It("should start healthy", func() {
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		status, _ := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusHealthy))
+	})
+
+	It("should track stats", func() {
+		record(3, nil, 10*time.Millisecond)
+		record(1, errPing, 50*time.Millisecond)
+
+		requests, errRatio, latency := subject.Stats()
+		g.Expect(requests).To(g.Equal(4))
+		g.Expect(errRatio).To(g.Equal(0.25))
+		g.Expect(latency).To(g.Equal(20 * time.Millisecond))
+
+		now = now.Add(4 * time.Second)
+		requests, _, _ = subject.Stats()
+		g.Expect(requests).To(g.Equal(0))
+	})
+
+	It("should evaluate error ratios", func() {
+		record(6, nil, time.Millisecond)
+		record(4, errPing, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		now = now.Add(time.Second)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusDegraded))
+		g.Expect(reason).To(g.Equal("error ratio of 0.40 exceeds 0.20"))
+
+		now = now.Add(time.Second)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		status, _ = subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+
+		// errors expire from the window
+		now = now.Add(3 * time.Second)
+		record(10, nil, time.Millisecond)
+		now = now.Add(time.Second)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+	})
+
+	It("should evaluate latencies", func() {
+		record(10, nil, 200*time.Millisecond)
+		for i := 0; i < 2; i++ {
+			now = now.Add(time.Second)
+			record(1, nil, 200*time.Millisecond)
+		}
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		_, reason := subject.CheckStatus()
+		g.Expect(reason).To(g.Equal("mean latency of 200ms exceeds 100ms"))
+	})
+
+	It("should recover once traffic stops", func() {
+		record(10, errPing, time.Millisecond)
+		for i := 0; i < 2; i++ {
+			now = now.Add(time.Second)
+			record(1, errPing, time.Millisecond)
+		}
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		// idle, errors expire from the window
+		now = now.Add(time.Hour)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusHealthy))
+		g.Expect(reason).To(g.BeEmpty())
+	})
+
+	It("should recover under light traffic", func() {
+		subject = NewPassive(&PassiveOptions{Window: 4 * time.Second, Buckets: 4, MinRequests: 5, Rise: 2})
+		subject.now = func() time.Time { return now }
+
+		record(10, errPing, time.Millisecond)
+		now = now.Add(time.Second)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		now = now.Add(time.Hour)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		now = now.Add(time.Second)
+		record(1, nil, time.Millisecond)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+	})
+
+	It("should not fail windows with too few requests", func() {
+		record(3, errPing, time.Millisecond)
+		for i := 0; i < 2; i++ {
+			now = now.Add(time.Second)
+			record(1, errPing, time.Millisecond)
+		}
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		for i := 0; i < 2; i++ {
+			now = now.Add(time.Second)
+			record(1, errPing, time.Millisecond)
+		}
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+	})
+
+})
+
+func BenchmarkPassive_Record(b *testing.B) {
+	passive := NewPassive(nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		passive.Record(nil, time.Millisecond)
+	}
+}
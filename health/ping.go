@@ -1,21 +1,54 @@
 package health
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"gopkg.in/tomb.v2"
 )
 
+// ErrTimeout is recorded when a ping does not complete within its timeout
+var ErrTimeout = errors.New("health: ping timed out")
+
 // Ping is a continous ping health check
 type Ping struct {
-	pinger     func() error
-	inter      time.Duration
-	rise, fall int
+	pinger         func(context.Context) error
+	timeout        time.Duration
+	inter          time.Duration
+	unhealthyInter time.Duration
+	immediate      bool
+	jitter         float64
+	maxLatency     time.Duration
+	riseRatio      float64
+	fallRatio      float64
+	flapHigh       float64
+	flapLow        float64
+	flapStatus     Status
+	rnd            *rand.Rand
+
+	ctx    context.Context
+	cancel context.CancelFunc
 
-	successes, fails, healthy int32
+	state       hysteresis
+	flapping    int32
+	transitions int64
+
+	lastErr                  error
+	lastSuccess, lastFailure time.Time
+	window                   *window
+	ratios, flaps            *window
+	latency                  *histogram
+	slow                     time.Duration
+	listeners                []*pingListener
+	mu                       sync.Mutex
 
 	closer tomb.Tomb
+	sched  *Scheduler
 }
 
 // NewPing creates a continous ping health check.
@@ -24,55 +57,445 @@ type Ping struct {
 // The rise parameter sets the number of subsequent checks the ping must pass to be declared healthy.
 // The fall parameter sets the number of subsequent failures that would mark the ping as unhealthy.
 func NewPing(pinger func() error, inter time.Duration, rise, fall int) *Ping {
-	ping := &Ping{
-		pinger: pinger,
-		inter:  inter,
-		rise:   rise,
-		fall:   fall,
+	return NewPingContext(func(_ context.Context) error {
+		return pinger()
+	}, 0, inter, rise, fall)
+}
+
+// NewPingContext creates a continous ping health check with a context-aware pinger.
+//
+// The timeout parameter limits the duration of each individual ping, a ping that
+// does not complete in time is counted as a failure. A zero timeout disables the limit.
+// A pinger that panics is also counted as a failure. See NewPing for the
+// remaining parameters.
+func NewPingContext(pinger func(context.Context) error, timeout, inter time.Duration, rise, fall int) *Ping {
+	return NewPingWithOptions(pinger, &PingOptions{
+		Interval: inter,
+		Timeout:  timeout,
+		Rise:     rise,
+		Fall:     fall,
+	})
+}
+
+// PingOptions contain optional ping settings
+type PingOptions struct {
+	// Interval between pings. Default: 1s
+	Interval time.Duration
+	// UnhealthyInterval is the interval between pings while the ping
+	// is unhealthy. Default: same as Interval
+	UnhealthyInterval time.Duration
+	// Timeout limits the duration of each individual ping. Default: 0 (no timeout)
+	Timeout time.Duration
+	// Rise is the number of subsequent pings that must pass to be declared healthy. Default: 1
+	Rise int
+	// Fall is the number of subsequent pings that must fail to be declared unhealthy. Default: 1
+	Fall int
+	// Healthy sets the initial state. Default: false
+	Healthy bool
+	// Immediate performs the first ping immediately on start instead of
+	// after the first interval. Default: false
+	Immediate bool
+	// Jitter randomises intervals by up to the given fraction, i.e.
+	// a value of 0.1 applies a random jitter of ±10%. Default: 0
+	Jitter float64
+	// Window is the number of recent pings to retain for statistics. Default: 100
+	Window int
+	// DegradedLatency marks a healthy ping as degraded when the p99 latency
+	// of recent pings exceeds the given value. Default: 0 (disabled)
+	DegradedLatency time.Duration
+
+	// RatioWindow enables ratio mode, where the state is determined by the ratio
+	// of successful pings within a trailing window of the given size rather than
+	// by subsequent passes and failures. Rise and Fall are ignored in ratio mode
+	// and the state is only determined once the window is filled. Default: 0 (disabled)
+	RatioWindow int
+	// RiseRatio is the minimum ratio of successful pings to be declared healthy
+	// in ratio mode. Default: 0.8
+	RiseRatio float64
+	// FallRatio is the ratio of successful pings below which the ping is declared
+	// unhealthy in ratio mode. Default: 0.5
+	FallRatio float64
+
+	// FlapWindow enables flap detection, across the given number of recent pings.
+	// Similar to Nagios, detection is based on a weighted percentage of outcome
+	// changes, more recent changes carry more weight. Detection starts once the
+	// window is filled. Default: 0 (disabled)
+	FlapWindow int
+	// FlapHigh is the percentage of outcome changes above which a ping is considered
+	// to be flapping. Default: 0.5
+	FlapHigh float64
+	// FlapLow is the percentage of outcome changes below which a flapping ping is
+	// considered to have stopped flapping. Default: 0.25
+	FlapLow float64
+	// FlapStatus is the status held while the ping is flapping. Default: StatusUnhealthy
+	FlapStatus Status
+}
+
+func (o *PingOptions) norm() *PingOptions {
+	var oo PingOptions
+	if o != nil {
+		oo = *o
 	}
+
+	if oo.Interval <= 0 {
+		oo.Interval = time.Second
+	}
+	if oo.UnhealthyInterval <= 0 {
+		oo.UnhealthyInterval = oo.Interval
+	}
+	if oo.Rise < 1 {
+		oo.Rise = 1
+	}
+	if oo.Fall < 1 {
+		oo.Fall = 1
+	}
+	if oo.Window < 1 {
+		oo.Window = 100
+	}
+	if oo.RiseRatio <= 0 {
+		oo.RiseRatio = 0.8
+	}
+	if oo.FallRatio <= 0 {
+		oo.FallRatio = 0.5
+	}
+	if oo.FlapHigh <= 0 {
+		oo.FlapHigh = 0.5
+	}
+	if oo.FlapLow <= 0 {
+		oo.FlapLow = 0.25
+	}
+	if oo.Jitter < 0 {
+		oo.Jitter = 0
+	} else if oo.Jitter > 1 {
+		oo.Jitter = 1
+	}
+	return &oo
+}
+
+// NewPingWithOptions creates a continous ping health check using custom options.
+// Options are optional and may be nil.
+func NewPingWithOptions(pinger func(context.Context) error, opts *PingOptions) *Ping {
+	ping := newPing(pinger, opts)
 	ping.closer.Go(ping.loop)
 	return ping
 }
 
+func newPing(pinger func(context.Context) error, opts *PingOptions) *Ping {
+	opts = opts.norm()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ping := &Ping{
+		pinger:         pinger,
+		timeout:        opts.Timeout,
+		inter:          opts.Interval,
+		unhealthyInter: opts.UnhealthyInterval,
+		state:          newHysteresis(opts.Rise, opts.Fall, opts.Healthy),
+		immediate:      opts.Immediate,
+		jitter:         opts.Jitter,
+		maxLatency:     opts.DegradedLatency,
+		riseRatio:      opts.RiseRatio,
+		fallRatio:      opts.FallRatio,
+		flapHigh:       opts.FlapHigh,
+		flapLow:        opts.FlapLow,
+		flapStatus:     opts.FlapStatus,
+		rnd:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		window:         newWindow(opts.Window),
+		latency:        newHistogram(latencyBuckets),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	if opts.RatioWindow > 0 {
+		ping.ratios = newWindow(opts.RatioWindow)
+	}
+	if opts.FlapWindow > 1 {
+		ping.flaps = newWindow(opts.FlapWindow)
+	}
+	return ping
+}
+
 // IsHealthy implements Check interface
 func (p *Ping) IsHealthy() bool {
-	return atomic.LoadInt32(&p.healthy) > 0
+	if p.IsFlapping() {
+		return p.flapStatus != StatusUnhealthy
+	}
+	return p.state.IsHealthy()
+}
+
+// IsFlapping returns true if the ping is currently flapping
+func (p *Ping) IsFlapping() bool {
+	return atomic.LoadInt32(&p.flapping) > 0
+}
+
+// CheckStatus implements StatusChecker interface. A healthy ping is
+// considered degraded while it is failing, but has not reached the fall
+// threshold yet or when recent ping latencies exceed the degraded latency
+// threshold.
+func (p *Ping) CheckStatus() (Status, string) {
+	if p.IsFlapping() {
+		return p.flapStatus, "flapping"
+	}
+	healthy := p.IsHealthy()
+
+	p.mu.Lock()
+	err, slow := p.lastErr, p.slow
+	p.mu.Unlock()
+
+	switch {
+	case !healthy && err != nil:
+		return StatusUnhealthy, err.Error()
+	case !healthy:
+		return StatusUnhealthy, "awaiting successful pings"
+	case err != nil:
+		return StatusDegraded, err.Error()
+	case slow != 0:
+		return StatusDegraded, fmt.Sprintf("p99 latency of %s exceeds %s", slow, p.maxLatency)
+	}
+	return StatusHealthy, ""
+}
+
+// LastError returns the error of the most recent probe, if any
+func (p *Ping) LastError() error {
+	p.mu.Lock()
+	err := p.lastErr
+	p.mu.Unlock()
+	return err
+}
+
+// Status returns a snapshot of the current ping status
+func (p *Ping) Status() *PingStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pcs := p.window.Percentiles(0.5, 0.99)
+	successes, fails := p.state.Counts()
+	return &PingStatus{
+		Healthy:      p.IsHealthy(),
+		LastError:    p.lastErr,
+		LastSuccess:  p.lastSuccess,
+		LastFailure:  p.lastFailure,
+		Successes:    successes,
+		Failures:     fails,
+		Transitions:  atomic.LoadInt64(&p.transitions),
+		Flapping:     p.IsFlapping(),
+		Probes:       p.window.Len(),
+		SuccessRatio: p.window.SuccessRatio(),
+		LatencyP50:   pcs[0],
+		LatencyP99:   pcs[1],
+	}
+}
+
+// OnChange registers a listener which is called every time the
+// ping transitions between healthy and unhealthy. Listeners are
+// called synchronously from the ping's loop and must not block.
+// The returned function removes the listener again.
+func (p *Ping) OnChange(fn func(healthy bool, lastErr error)) func() {
+	l := &pingListener{fn: fn}
+
+	p.mu.Lock()
+	p.listeners = append(p.listeners, l)
+	p.mu.Unlock()
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		listeners := make([]*pingListener, 0, len(p.listeners))
+		for _, x := range p.listeners {
+			if x != l {
+				listeners = append(listeners, x)
+			}
+		}
+		p.listeners = listeners
+	}
 }
 
-// Stop stops the pinger
+// Stop stops the pinger and waits for a running ping to complete
 func (p *Ping) Stop() {
+	p.cancel()
+	if p.sched != nil {
+		p.sched.remove(p)
+		return
+	}
 	p.closer.Kill(nil)
 	p.closer.Wait()
 }
 
 func (p *Ping) loop() error {
+	if p.immediate {
+		p.run()
+	}
+
+	timer := time.NewTimer(p.next())
+	defer timer.Stop()
+
 	for {
 		select {
 		case <-p.closer.Dying():
 			return nil
-		case <-time.After(p.inter):
-			p.update(p.pinger() == nil)
+		case <-timer.C:
+			p.run()
+			timer.Reset(p.next())
 		}
 	}
 }
 
-func (p *Ping) update(ok bool) {
-	if ok {
-		atomic.StoreInt32(&p.fails, 0)
+// next returns the delay until the next ping
+func (p *Ping) next() time.Duration {
+	d := p.inter
+	if !p.IsHealthy() {
+		d = p.unhealthyInter
+	}
+	if p.jitter > 0 {
+		d += time.Duration((2*p.rnd.Float64() - 1) * p.jitter * float64(d))
+	}
+	return d
+}
 
-		n := int(atomic.AddInt32(&p.successes, 1))
-		if n > p.rise {
-			atomic.AddInt32(&p.successes, -1)
-		} else if n == p.rise {
-			atomic.CompareAndSwapInt32(&p.healthy, 0, 1)
+// run performs a single ping and updates the status. Probes
+// interrupted by Stop are discarded.
+func (p *Ping) run() {
+	start := time.Now()
+	err := p.probe()
+	if p.ctx.Err() != nil {
+		return
+	}
+	p.record(err, start, time.Since(start))
+	p.update(err)
+}
+
+func (p *Ping) probe() error {
+	if p.timeout <= 0 {
+		return safePing(p.ctx, p.pinger)
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() { errs <- safePing(ctx, p.pinger) }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrTimeout
 		}
+		return ctx.Err()
+	}
+}
+
+func (p *Ping) record(err error, at time.Time, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.lastSuccess = at
 	} else {
-		atomic.StoreInt32(&p.successes, 0)
+		p.lastFailure = at
+	}
+	p.window.Add(sample{latency: latency, ok: err == nil})
+	p.latency.Observe(latency.Seconds())
 
-		if n := int(atomic.AddInt32(&p.fails, 1)); n > p.fall {
-			atomic.AddInt32(&p.fails, -1)
-		} else if n == p.fall {
-			atomic.CompareAndSwapInt32(&p.healthy, 1, 0)
+	if p.maxLatency > 0 {
+		if p99 := p.window.Percentiles(0.99)[0]; p99 > p.maxLatency {
+			p.slow = p99
+		} else {
+			p.slow = 0
 		}
 	}
 }
+
+func (p *Ping) update(err error) {
+	ok := err == nil
+	before := p.IsHealthy()
+
+	p.mu.Lock()
+	p.lastErr = err
+	if p.ratios != nil {
+		p.ratios.Add(sample{ok: ok})
+		if p.ratios.Full() {
+			ratio := p.ratios.SuccessRatio()
+			if ratio >= p.riseRatio {
+				p.state.Set(true)
+			} else if ratio < p.fallRatio {
+				p.state.Set(false)
+			}
+		}
+	} else {
+		p.state.Update(ok)
+	}
+	if p.flaps != nil {
+		p.flaps.Add(sample{ok: ok})
+		if !p.flaps.Full() {
+			// wait for the window to fill
+		} else if change := p.flaps.StateChange(); change >= p.flapHigh {
+			atomic.StoreInt32(&p.flapping, 1)
+		} else if change < p.flapLow {
+			atomic.StoreInt32(&p.flapping, 0)
+		}
+	}
+	p.mu.Unlock()
+
+	if after := p.IsHealthy(); after != before {
+		p.notify(after, err)
+	}
+}
+
+func (p *Ping) notify(healthy bool, err error) {
+	atomic.AddInt64(&p.transitions, 1)
+
+	p.mu.Lock()
+	listeners := p.listeners
+	p.mu.Unlock()
+
+	for _, l := range listeners {
+		l.fn(healthy, err)
+	}
+}
+
+type pingListener struct {
+	fn func(bool, error)
+}
+
+func safePing(ctx context.Context, pinger func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("health: ping panicked: %v", r)
+		}
+	}()
+	return pinger(ctx)
+}
+
+// PingStatus is a snapshot of the ping status
+type PingStatus struct {
+	// Healthy is the current state
+	Healthy bool
+	// LastError is the error returned by the most recent ping
+	LastError error
+	// LastSuccess and LastFailure are the start times
+	// of the most recent successful and failed pings
+	LastSuccess, LastFailure time.Time
+	// Successes and Failures are the numbers of consecutive
+	// passes and failures, capped at rise and fall respectively
+	Successes, Failures int
+	// Transitions is the number of times the ping changed between
+	// healthy and unhealthy
+	Transitions int64
+	// Flapping is true while the ping is flapping
+	Flapping bool
+	// Probes is the number of recent pings included in the statistics
+	Probes int
+	// SuccessRatio is the ratio of successful recent pings
+	SuccessRatio float64
+	// LatencyP50 and LatencyP99 are percentiles of recent ping latencies
+	LatencyP50, LatencyP99 time.Duration
+}
+
+// latencyHistogram returns a snapshot of the cumulative latency histogram
+func (p *Ping) latencyHistogram() *histogram {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.latency.Copy()
+}
@@ -1,13 +1,18 @@
 package health
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
-	. "github.com/onsi/gomega"
+	g "github.com/onsi/gomega"
 )
 
+var errPing = errors.New("ping failed")
+
 var _ = Describe("Ping", func() {
 	var subject *Ping
 
@@ -22,25 +27,308 @@ var _ = Describe("Ping", func() {
 	})
 
 	It("should update health status", func() {
-		Expect(subject.IsHealthy()).To(BeFalse())
-		subject.update(true)
-		Expect(subject.IsHealthy()).To(BeFalse())
-		subject.update(true)
-		Expect(subject.IsHealthy()).To(BeTrue())
-		subject.update(true)
-		Expect(subject.IsHealthy()).To(BeTrue())
-		subject.update(false)
-		Expect(subject.IsHealthy()).To(BeTrue())
-		subject.update(false)
-		Expect(subject.IsHealthy()).To(BeTrue())
-		subject.update(false)
-		Expect(subject.IsHealthy()).To(BeFalse())
-		subject.update(false)
-		Expect(subject.IsHealthy()).To(BeFalse())
-		subject.update(true)
-		Expect(subject.IsHealthy()).To(BeFalse())
-		subject.update(true)
-		Expect(subject.IsHealthy()).To(BeTrue())
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		subject.update(nil)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		subject.update(nil)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		subject.update(nil)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		subject.update(errPing)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		subject.update(errPing)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		subject.update(errPing)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		subject.update(errPing)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		subject.update(nil)
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		subject.update(nil)
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+	})
+
+	It("should track last errors", func() {
+		g.Expect(subject.LastError()).To(g.BeNil())
+		subject.update(errPing)
+		g.Expect(subject.LastError()).To(g.Equal(errPing))
+		subject.update(nil)
+		g.Expect(subject.LastError()).To(g.BeNil())
+	})
+
+	It("should notify listeners on transitions", func() {
+		type event struct {
+			healthy bool
+			err     error
+		}
+		var events []event
+		subject.OnChange(func(healthy bool, err error) {
+			events = append(events, event{healthy, err})
+		})
+
+		for i := 0; i < 3; i++ {
+			subject.update(nil)
+		}
+		for i := 0; i < 4; i++ {
+			subject.update(errPing)
+		}
+		subject.update(nil)
+		g.Expect(events).To(g.Equal([]event{
+			{true, nil},
+			{false, errPing},
+		}))
+	})
+
+	It("should remove listeners", func() {
+		var n int
+		cancel := subject.OnChange(func(bool, error) { n++ })
+		subject.OnChange(func(bool, error) {})
+		g.Expect(subject.listeners).To(g.HaveLen(2))
+
+		cancel()
+		cancel()
+		g.Expect(subject.listeners).To(g.HaveLen(1))
+
+		subject.update(errPing)
+		subject.update(errPing)
+		g.Expect(n).To(g.Equal(0))
+	})
+
+	It("should time out probes", func() {
+		ping := NewPingContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}, time.Millisecond, time.Hour, 1, 1)
+		defer ping.Stop()
+
+		g.Expect(ping.probe()).To(g.Equal(ErrTimeout))
+
+		ping = NewPingContext(func(_ context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}, time.Millisecond, time.Hour, 1, 1)
+		defer ping.Stop()
+
+		g.Expect(ping.probe()).To(g.Equal(ErrTimeout))
+	})
+
+	It("should recover from panics", func() {
+		ping := NewPingContext(func(_ context.Context) error {
+			panic("boom")
+		}, time.Second, time.Hour, 1, 1)
+		defer ping.Stop()
+
+		g.Expect(ping.probe()).To(g.MatchError("health: ping panicked: boom"))
+
+		ping = NewPing(func() error {
+			panic("boom")
+		}, time.Hour, 1, 1)
+		defer ping.Stop()
+
+		g.Expect(ping.probe()).To(g.MatchError("health: ping panicked: boom"))
+	})
+
+	It("should record probe failures", func() {
+		ping := NewPingContext(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}, time.Millisecond, time.Millisecond, 1, 1)
+		defer ping.Stop()
+
+		g.Eventually(ping.LastError, "50ms", "2ms").Should(g.Equal(ErrTimeout))
+		g.Expect(ping.IsHealthy()).To(g.BeFalse())
+	})
+
+	It("should discard probes interrupted by stop", func() {
+		started := make(chan struct{})
+		ping := NewPingWithOptions(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, &PingOptions{Interval: time.Hour, Healthy: true, Immediate: true})
+
+		var changes int32
+		ping.OnChange(func(bool, error) { atomic.AddInt32(&changes, 1) })
+		g.Eventually(started, "50ms").Should(g.BeClosed())
+
+		ping.Stop()
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+		g.Expect(ping.LastError()).NotTo(g.HaveOccurred())
+		g.Expect(ping.Status().Probes).To(g.Equal(0))
+		g.Expect(atomic.LoadInt32(&changes)).To(g.Equal(int32(0)))
+	})
+
+	It("should accept options", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, Healthy: true, Rise: 2, Fall: 2})
+		defer ping.Stop()
+
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+		ping.update(errPing)
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+		ping.update(errPing)
+		g.Expect(ping.IsHealthy()).To(g.BeFalse())
+	})
+
+	It("should probe immediately", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, Immediate: true})
+		defer ping.Stop()
+
+		g.Eventually(ping.IsHealthy, "20ms", "2ms").Should(g.BeTrue())
+	})
+
+	It("should calculate intervals", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, UnhealthyInterval: 2 * time.Hour})
+		ping.Stop()
+
+		g.Expect(ping.next()).To(g.Equal(2 * time.Hour))
+		ping.update(nil)
+		g.Expect(ping.next()).To(g.Equal(time.Hour))
+
+		ping.jitter = 0.1
+		for i := 0; i < 100; i++ {
+			g.Expect(ping.next()).To(g.BeNumerically("~", time.Hour, 6*time.Minute))
+		}
+	})
+
+	It("should report status", func() {
+		start := time.Now()
+		for i := 1; i <= 10; i++ {
+			var err error
+			if i%5 == 0 {
+				err = errPing
+			}
+			subject.record(err, start.Add(time.Duration(i)*time.Second), time.Duration(i)*time.Millisecond)
+			subject.update(err)
+		}
+
+		status := subject.Status()
+		g.Expect(status.Healthy).To(g.BeTrue())
+		g.Expect(status.LastError).To(g.Equal(errPing))
+		g.Expect(status.LastSuccess).To(g.Equal(start.Add(9 * time.Second)))
+		g.Expect(status.LastFailure).To(g.Equal(start.Add(10 * time.Second)))
+		g.Expect(status.Successes).To(g.Equal(0))
+		g.Expect(status.Failures).To(g.Equal(1))
+		g.Expect(status.Probes).To(g.Equal(10))
+		g.Expect(status.SuccessRatio).To(g.BeNumerically("~", 0.8, 0.001))
+		g.Expect(status.LatencyP50).To(g.Equal(5 * time.Millisecond))
+		g.Expect(status.LatencyP99).To(g.Equal(10 * time.Millisecond))
+	})
+
+	It("should check status", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, Rise: 1, Fall: 2, DegradedLatency: 10 * time.Millisecond})
+		defer ping.Stop()
+
+		level := func() Status {
+			s, _ := ping.CheckStatus()
+			return s
+		}
+
+		g.Expect(level()).To(g.Equal(StatusUnhealthy))
+		ping.record(nil, time.Now(), time.Millisecond)
+		ping.update(nil)
+		g.Expect(level()).To(g.Equal(StatusHealthy))
+		ping.record(errPing, time.Now(), time.Millisecond)
+		ping.update(errPing)
+		g.Expect(level()).To(g.Equal(StatusDegraded))
+		ping.record(nil, time.Now(), 20*time.Millisecond)
+		ping.update(nil)
+
+		status, reason := ping.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusDegraded))
+		g.Expect(reason).To(g.Equal("p99 latency of 20ms exceeds 10ms"))
+
+		ping.update(errPing)
+		ping.update(errPing)
+		status, reason = ping.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("ping failed"))
+	})
+
+	It("should support ratio mode", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, RatioWindow: 4, RiseRatio: 0.75, FallRatio: 0.5})
+		defer ping.Stop()
+
+		outcomes := []struct {
+			err     error
+			healthy bool
+		}{
+			{nil, false},
+			{nil, false},
+			{nil, false},
+			{errPing, true},  // 0.75
+			{nil, true},      // 0.75
+			{errPing, true},  // 0.5
+			{errPing, false}, // 0.25
+			{nil, false},     // 0.5
+			{nil, false},     // 0.5
+			{nil, true},      // 0.75
+		}
+		for i, o := range outcomes {
+			ping.update(o.err)
+			g.Expect(ping.IsHealthy()).To(g.Equal(o.healthy), "at #%d", i)
+		}
+	})
+
+	It("should detect flapping", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, Healthy: true, FlapWindow: 6, FlapHigh: 0.5, FlapLow: 0.25})
+		defer ping.Stop()
+
+		var events []bool
+		ping.OnChange(func(healthy bool, _ error) { events = append(events, healthy) })
+
+		for _, err := range []error{nil, errPing, nil, errPing, nil} {
+			ping.update(err)
+		}
+		g.Expect(ping.IsFlapping()).To(g.BeFalse())
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+
+		ping.update(errPing)
+		g.Expect(ping.IsFlapping()).To(g.BeTrue())
+		g.Expect(ping.IsHealthy()).To(g.BeFalse())
+		g.Expect(ping.Status().Flapping).To(g.BeTrue())
+
+		status, reason := ping.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("flapping"))
+
+		for i := 0; i < 4; i++ {
+			ping.update(nil)
+		}
+		g.Expect(ping.IsFlapping()).To(g.BeTrue())
+		g.Expect(ping.IsHealthy()).To(g.BeFalse())
+
+		ping.update(nil)
+		g.Expect(ping.IsFlapping()).To(g.BeFalse())
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+		g.Expect(events).To(g.Equal([]bool{false, true, false, true, false, true}))
+	})
+
+	It("should hold flapping pings in a configurable state", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Hour, FlapWindow: 4, FlapStatus: StatusDegraded})
+		defer ping.Stop()
+
+		for _, err := range []error{nil, errPing, nil, errPing} {
+			ping.update(err)
+		}
+		g.Expect(ping.IsFlapping()).To(g.BeTrue())
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+
+		status, _ := ping.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusDegraded))
 	})
 
 	It("should check periodically", func() {
@@ -49,8 +337,8 @@ var _ = Describe("Ping", func() {
 		}, time.Millisecond, 2, 3)
 		defer ping.Stop()
 
-		Expect(ping.IsHealthy()).To(BeFalse())
-		Eventually(ping.IsHealthy, "20ms", "2ms").Should(BeTrue())
+		g.Expect(ping.IsHealthy()).To(g.BeFalse())
+		g.Eventually(ping.IsHealthy, "20ms", "2ms").Should(g.BeTrue())
 	})
 
 })
@@ -71,6 +359,6 @@ func BenchmarkPing_update(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		ping.update(true)
+		ping.update(nil)
 	}
 }
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+)
+
+// ContextPinger is implemented by any client with a context-aware Ping method.
+type ContextPinger interface {
+	Ping(context.Context) error
+}
+
+// PingerOf adapts a ContextPinger for use with NewPingContext or NewPingWithOptions.
+func PingerOf(p ContextPinger) func(context.Context) error {
+	return p.Ping
+}
+
+// TCPPinger returns a pinger which dials a TCP address.
+func TCPPinger(addr string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// maxHTTPPingBody limits the amount of response body that is
+// inspected by HTTP pingers
+const maxHTTPPingBody = 64 * 1024
+
+// HTTPPingerOptions contain optional HTTP pinger settings
+type HTTPPingerOptions struct {
+	// Client is the HTTP client to use. Default: http.DefaultClient
+	Client *http.Client
+	// MinStatus is the minimum accepted response status code. Default: 200
+	MinStatus int
+	// MaxStatus is the maximum accepted response status code. Default: 399
+	MaxStatus int
+	// Contains requires the response body to contain a substring. Only the
+	// first 64KiB of the body are inspected. Default: "" (disabled)
+	Contains string
+}
+
+func (o *HTTPPingerOptions) norm() *HTTPPingerOptions {
+	var oo HTTPPingerOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.Client == nil {
+		oo.Client = http.DefaultClient
+	}
+	if oo.MinStatus == 0 {
+		oo.MinStatus = 200
+	}
+	if oo.MaxStatus == 0 {
+		oo.MaxStatus = 399
+	}
+	return &oo
+}
+
+// HTTPPinger returns a pinger which issues GET requests to a URL.
+// Options are optional and may be nil.
+func HTTPPinger(url string, opts *HTTPPingerOptions) func(context.Context) error {
+	opts = opts.norm()
+	return func(ctx context.Context) error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := opts.Client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPPingBody))
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode < opts.MinStatus || resp.StatusCode > opts.MaxStatus {
+			return fmt.Errorf("health: unexpected response status %d from %s", resp.StatusCode, url)
+		}
+		if opts.Contains != "" && !bytes.Contains(body, []byte(opts.Contains)) {
+			return fmt.Errorf("health: response from %s does not contain %q", url, opts.Contains)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+//go:build go1.8
+// +build go1.8
+
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+)
+
+// SQLPinger returns a pinger which verifies database connections.
+// Requires Go 1.8 or later.
+func SQLPinger(db *sql.DB) func(context.Context) error {
+	return db.PingContext
+}
+
+// DNSPinger returns a pinger which resolves a host name. If no resolver is
+// given, the default resolver is used. Requires Go 1.8 or later.
+func DNSPinger(host string, resolver *net.Resolver) func(context.Context) error {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return func(ctx context.Context) error {
+		addrs, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("health: no addresses found for %s", host)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+//go:build go1.8
+// +build go1.8
+
+package health
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pingers", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("should ping DNS", func() {
+		g.Expect(DNSPinger("localhost", nil)(ctx)).To(g.Succeed())
+		g.Expect(DNSPinger("unknown.invalid", nil)(ctx)).NotTo(g.Succeed())
+	})
+
+	It("should ping SQL", func() {
+		db, err := sql.Open("health-mock", "ok")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer db.Close()
+		g.Expect(SQLPinger(db)(ctx)).To(g.Succeed())
+
+		db, err = sql.Open("health-mock", "bad")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer db.Close()
+		g.Expect(SQLPinger(db)(ctx)).To(g.Equal(errPing))
+	})
+
+})
+
+// --------------------------------------------------------------------
+
+func init() {
+	sql.Register("health-mock", mockDriver{})
+}
+
+type mockDriver struct{}
+
+func (mockDriver) Open(name string) (driver.Conn, error) {
+	if name != "ok" {
+		return nil, errPing
+	}
+	return mockConn{}, nil
+}
+
+type mockConn struct{}
+
+func (mockConn) Prepare(_ string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (mockConn) Close() error                          { return nil }
+func (mockConn) Begin() (driver.Tx, error)             { return nil, errors.New("not implemented") }
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pingers", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("should ping TCP", func() {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		addr := lis.Addr().String()
+
+		g.Expect(TCPPinger(addr)(ctx)).To(g.Succeed())
+		g.Expect(lis.Close()).To(g.Succeed())
+		g.Expect(TCPPinger(addr)(ctx)).NotTo(g.Succeed())
+	})
+
+	It("should ping HTTP", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/ok":
+				fmt.Fprint(w, "status: OK")
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer srv.Close()
+
+		g.Expect(HTTPPinger(srv.URL+"/ok", nil)(ctx)).To(g.Succeed())
+		g.Expect(HTTPPinger(srv.URL+"/ok", &HTTPPingerOptions{Contains: "OK"})(ctx)).To(g.Succeed())
+		g.Expect(HTTPPinger(srv.URL+"/ok", &HTTPPingerOptions{Contains: "FAIL"})(ctx)).To(g.MatchError(`health: response from ` + srv.URL + `/ok does not contain "FAIL"`))
+		g.Expect(HTTPPinger(srv.URL+"/missing", nil)(ctx)).To(g.MatchError(`health: unexpected response status 404 from ` + srv.URL + `/missing`))
+		g.Expect(HTTPPinger(srv.URL+"/missing", &HTTPPingerOptions{MaxStatus: 404})(ctx)).To(g.Succeed())
+	})
+
+	It("should adapt context pingers", func() {
+		g.Expect(PingerOf(mockPinger{})(ctx)).To(g.Succeed())
+		g.Expect(PingerOf(mockPinger{err: errPing})(ctx)).To(g.Equal(errPing))
+	})
+
+})
+
+// --------------------------------------------------------------------
+
+type mockPinger struct{ err error }
+
+func (p mockPinger) Ping(_ context.Context) error { return p.err }
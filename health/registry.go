@@ -0,0 +1,426 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errNoName        = errors.New("health: check name must not be blank")
+	errNoCheck       = errors.New("health: check must not be nil")
+	errAlreadyExists = errors.New("health: check with this name is already registered")
+)
+
+// CheckOptions contain optional registration settings
+type CheckOptions struct {
+	// Critical checks determine the overall health of the registry,
+	// non-critical checks are reported only.
+	Critical bool
+	// Tags are arbitrary labels, e.g. for filtering reports.
+	Tags []string
+	// DependsOn lists the names of other checks this check depends on.
+	// Unhealthy checks with unhealthy dependencies are reported as
+	// blocked rather than failing independently. Dependencies do not
+	// need to be registered in order, but must not be cyclic.
+	DependsOn []string
+}
+
+// Registry is a collection of named checks. A registry is itself
+// a Check and is healthy as long as all critical checks are healthy.
+//
+// The registry also implements the StatusChecker interface. It is degraded
+// when any of the critical checks are degraded or when any of the non-critical
+// checks are degraded or unhealthy.
+//
+// Registered checks are published as immutable snapshots, so IsHealthy,
+// CheckStatus and IsDraining never block on (un-)registrations and do not
+// contend with each other.
+type Registry struct {
+	entries atomic.Value // []*registryEntry, replaced on every change
+	index   map[string]*registryEntry
+	mu      sync.RWMutex
+}
+
+// NewRegistry creates a new, empty registry
+func NewRegistry() *Registry {
+	return &Registry{index: make(map[string]*registryEntry)}
+}
+
+// Register adds a check under a unique name. Options are optional and
+// may be nil.
+func (r *Registry) Register(name string, check Check, opts *CheckOptions) error {
+	if name == "" {
+		return errNoName
+	}
+	if check == nil {
+		return errNoCheck
+	}
+
+	e := &registryEntry{name: name, check: check}
+	if opts != nil {
+		e.critical = opts.Critical
+		e.tags = append(e.tags, opts.Tags...)
+		e.deps = append(e.deps, opts.DependsOn...)
+	}
+	e.observe(time.Now())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.index[name]; ok {
+		return errAlreadyExists
+	}
+	if path := r.findCycle(name, e.deps, []string{name}); path != nil {
+		return fmt.Errorf("health: cyclic dependency %s", strings.Join(path, " -> "))
+	}
+	entries := r.snapshot()
+	r.index[name] = e
+	r.entries.Store(append(entries[:len(entries):len(entries)], e))
+
+	if n, ok := check.(notifier); ok {
+		e.cancel = n.OnChange(e.changed)
+	}
+	return nil
+}
+
+// Unregister removes a check by name
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.index[name]
+	if !ok {
+		return
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	delete(r.index, name)
+
+	entries := make([]*registryEntry, 0, len(r.index))
+	for _, x := range r.snapshot() {
+		if x != e {
+			entries = append(entries, x)
+		}
+	}
+	r.entries.Store(entries)
+}
+
+// Get returns a registered check by name
+func (r *Registry) Get(name string) (Check, bool) {
+	r.mu.RLock()
+	e, ok := r.index[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return e.check, true
+}
+
+// Names returns the sorted names of all registered checks
+func (r *Registry) Names() []string {
+	entries := r.snapshot()
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// IsHealthy implements Check interface
+func (r *Registry) IsHealthy() bool {
+	for _, e := range r.snapshot() {
+		if e.critical && !e.check.IsHealthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckStatus implements StatusChecker interface
+func (r *Registry) CheckStatus() (Status, string) {
+	status := StatusHealthy
+	var reasons []string
+	for _, e := range r.snapshot() {
+		s, _ := StatusOf(e.check)
+		if s == StatusHealthy {
+			continue
+		}
+
+		reasons = append(reasons, e.name+" is "+s.String())
+		if s = aggregateStatus(s, e.critical); s < status {
+			status = s
+		}
+	}
+	sort.Strings(reasons)
+	return status, strings.Join(reasons, ", ")
+}
+
+// IsDraining returns true if any of the registered checks is draining, see Drain
+func (r *Registry) IsDraining() bool {
+	for _, e := range r.snapshot() {
+		if d, ok := e.check.(drainer); ok && d.IsDraining() {
+			return true
+		}
+	}
+	return false
+}
+
+// Report returns a snapshot of the current state of all registered checks
+func (r *Registry) Report() *Report {
+	now := time.Now()
+
+	snapshot := r.snapshot()
+	entries := make([]ReportEntry, 0, len(snapshot))
+	for _, e := range snapshot {
+		entries = append(entries, e.report(now))
+	}
+
+	resolveBlocked(entries)
+	return newReport(entries)
+}
+
+// snapshot returns the currently registered entries.
+// The returned slice must not be modified.
+func (r *Registry) snapshot() []*registryEntry {
+	entries, _ := r.entries.Load().([]*registryEntry)
+	return entries
+}
+
+// findCycle returns the path of a cycle back to name, if the given
+// dependencies would introduce one
+func (r *Registry) findCycle(name string, deps []string, path []string) []string {
+	for _, dep := range deps {
+		if dep == name {
+			return append(path, dep)
+		}
+		if e, ok := r.index[dep]; ok {
+			if cycle := r.findCycle(name, e.deps, append(path, dep)); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------
+
+// Report is a point-in-time snapshot of a registry
+type Report struct {
+	// Healthy is true if all critical checks are healthy
+	Healthy bool `json:"healthy"`
+	// Status is the aggregate status of all checks
+	Status Status `json:"status"`
+	// Checks contains the individual check states, sorted by name
+	Checks []ReportEntry `json:"checks"`
+}
+
+func newReport(entries []ReportEntry) *Report {
+	sort.Sort(reportEntrySlice(entries))
+
+	rep := &Report{Healthy: true, Status: StatusHealthy, Checks: entries}
+	for _, e := range entries {
+		if e.Critical && !e.Healthy {
+			rep.Healthy = false
+		}
+		if s := aggregateStatus(e.Status, e.Critical); s < rep.Status {
+			rep.Status = s
+		}
+	}
+	return rep
+}
+
+// Get returns the entry for a named check
+func (r *Report) Get(name string) (ReportEntry, bool) {
+	for _, e := range r.Checks {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return ReportEntry{}, false
+}
+
+// RootCauses returns the names of all unhealthy checks
+// which are not blocked by any of their dependencies
+func (r *Report) RootCauses() []string {
+	var names []string
+	for _, e := range r.Checks {
+		if !e.Healthy && len(e.BlockedBy) == 0 {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+// Failing returns the names of all unhealthy checks
+func (r *Report) Failing() []string {
+	var names []string
+	for _, e := range r.Checks {
+		if !e.Healthy {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+// Filter returns a new report, containing only entries
+// which are tagged with at least one of the given tags
+func (r *Report) Filter(tags ...string) *Report {
+	entries := make([]ReportEntry, 0, len(r.Checks))
+	for _, e := range r.Checks {
+		if e.HasTag(tags...) {
+			entries = append(entries, e)
+		}
+	}
+	return newReport(entries)
+}
+
+// ReportEntry is the state of an individual check
+type ReportEntry struct {
+	Name      string    `json:"name"`
+	Critical  bool      `json:"critical"`
+	Tags      []string  `json:"tags,omitempty"`
+	DependsOn []string  `json:"depends_on,omitempty"`
+	Healthy   bool      `json:"healthy"`
+	Status    Status    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	Since     time.Time `json:"since"`
+	// BlockedBy contains the names of the root-cause dependencies
+	// if the check is unhealthy and blocked by them
+	BlockedBy []string `json:"blocked_by,omitempty"`
+	// Transitions is the number of observed status changes. Changes are
+	// observed when reports are generated or when checks notify
+	// about them (e.g. Ping).
+	Transitions int64 `json:"transitions"`
+}
+
+// HasTag returns true if the entry is tagged with any of the given tags
+func (e *ReportEntry) HasTag(tags ...string) bool {
+	for _, t := range tags {
+		for _, s := range e.Tags {
+			if s == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aggregateStatus returns the contribution of an individual
+// check status to the aggregate status
+func aggregateStatus(s Status, critical bool) Status {
+	if s == StatusUnhealthy && !critical {
+		return StatusDegraded
+	}
+	return s
+}
+
+// resolveBlocked marks unhealthy entries with unhealthy dependencies as blocked
+func resolveBlocked(entries []ReportEntry) {
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		index[e.Name] = i
+	}
+
+	var roots func(e *ReportEntry, seen map[string]bool) []string
+	roots = func(e *ReportEntry, seen map[string]bool) []string {
+		var res []string
+		for _, name := range e.DependsOn {
+			i, ok := index[name]
+			if !ok || seen[name] || entries[i].Healthy {
+				continue
+			}
+			seen[name] = true
+
+			if sub := roots(&entries[i], seen); len(sub) != 0 {
+				res = append(res, sub...)
+			} else {
+				res = append(res, name)
+			}
+		}
+		return res
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Healthy {
+			continue
+		}
+		if blockers := roots(e, map[string]bool{e.Name: true}); len(blockers) != 0 {
+			sort.Strings(blockers)
+			e.BlockedBy = blockers
+			e.Reason = "blocked by " + strings.Join(blockers, ", ")
+		}
+	}
+}
+
+type reportEntrySlice []ReportEntry
+
+func (p reportEntrySlice) Len() int           { return len(p) }
+func (p reportEntrySlice) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p reportEntrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// --------------------------------------------------------------------
+
+// notifier is implemented by checks which can notify about
+// state transitions, e.g. Ping
+type notifier interface {
+	OnChange(func(healthy bool, lastErr error)) func()
+}
+
+// drainer is implemented by checks which can be drained, e.g. Drain
+type drainer interface {
+	IsDraining() bool
+}
+
+type registryEntry struct {
+	name     string
+	check    Check
+	critical bool
+	tags     []string
+	deps     []string
+	cancel   func()
+
+	status, since int64 // status is offset by 1, zero means not observed yet
+	transitions   int64
+}
+
+// observe records the current status of the check and returns it, along
+// with the time of the last observed status change
+func (e *registryEntry) observe(now time.Time) (Status, string, time.Time) {
+	status, reason := StatusOf(e.check)
+	if prev := atomic.SwapInt64(&e.status, int64(status)+1); prev != int64(status)+1 {
+		atomic.StoreInt64(&e.since, now.UnixNano())
+		if prev != 0 {
+			atomic.AddInt64(&e.transitions, 1)
+		}
+	}
+	return status, reason, time.Unix(0, atomic.LoadInt64(&e.since))
+}
+
+func (e *registryEntry) changed(_ bool, _ error) {
+	e.observe(time.Now())
+}
+
+func (e *registryEntry) report(now time.Time) ReportEntry {
+	status, reason, since := e.observe(now)
+	return ReportEntry{
+		Name:        e.name,
+		Critical:    e.critical,
+		Tags:        e.tags,
+		DependsOn:   e.deps,
+		Healthy:     status != StatusUnhealthy,
+		Status:      status,
+		Reason:      reason,
+		Since:       since,
+		Transitions: atomic.LoadInt64(&e.transitions),
+	}
+}
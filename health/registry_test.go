@@ -0,0 +1,194 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var subject *Registry
+	var db, cache bool
+
+	BeforeEach(func() {
+		db, cache = true, true
+
+		subject = NewRegistry()
+		g.Expect(subject.Register("db", CheckFunc(func() bool { return db }), &CheckOptions{Critical: true, Tags: []string{"store"}})).To(g.Succeed())
+		g.Expect(subject.Register("cache", CheckFunc(func() bool { return cache }), nil)).To(g.Succeed())
+	})
+
+	It("should register checks", func() {
+		g.Expect(subject.Names()).To(g.Equal([]string{"cache", "db"}))
+		g.Expect(subject.Register("", CheckFunc(func() bool { return true }), nil)).To(g.Equal(errNoName))
+		g.Expect(subject.Register("db", nil, nil)).To(g.Equal(errNoCheck))
+		g.Expect(subject.Register("db", CheckFunc(func() bool { return true }), nil)).To(g.Equal(errAlreadyExists))
+
+		_, ok := subject.Get("db")
+		g.Expect(ok).To(g.BeTrue())
+		_, ok = subject.Get("missing")
+		g.Expect(ok).To(g.BeFalse())
+	})
+
+	It("should unregister checks", func() {
+		subject.Unregister("db")
+		subject.Unregister("missing")
+		g.Expect(subject.Names()).To(g.Equal([]string{"cache"}))
+	})
+
+	It("should not modify published snapshots", func() {
+		before := subject.snapshot()
+		g.Expect(before).To(g.HaveLen(2))
+
+		subject.Unregister("db")
+		g.Expect(subject.Register("queue", CheckFunc(func() bool { return true }), nil)).To(g.Succeed())
+		g.Expect(before).To(g.HaveLen(2))
+		g.Expect(before[0].name).To(g.Equal("db"))
+		g.Expect(before[1].name).To(g.Equal("cache"))
+		g.Expect(subject.snapshot()).To(g.HaveLen(2))
+	})
+
+	It("should detach listeners on unregister", func() {
+		ping := NewPing(func() error { return nil }, time.Hour, 1, 1)
+		defer ping.Stop()
+
+		for i := 0; i < 3; i++ {
+			g.Expect(subject.Register("ping", ping, nil)).To(g.Succeed())
+			subject.Unregister("ping")
+		}
+		g.Expect(subject.Register("ping", ping, nil)).To(g.Succeed())
+
+		ping.mu.Lock()
+		n := len(ping.listeners)
+		ping.mu.Unlock()
+		g.Expect(n).To(g.Equal(1))
+	})
+
+	It("should determine health from critical checks", func() {
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		cache = false
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		db = false
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+	})
+
+	It("should report", func() {
+		cache = false
+
+		rep := subject.Report()
+		g.Expect(rep.Healthy).To(g.BeTrue())
+		g.Expect(rep.Checks).To(g.HaveLen(2))
+		g.Expect(rep.Failing()).To(g.Equal([]string{"cache"}))
+
+		ent, ok := rep.Get("db")
+		g.Expect(ok).To(g.BeTrue())
+		g.Expect(ent.Name).To(g.Equal("db"))
+		g.Expect(ent.Critical).To(g.BeTrue())
+		g.Expect(ent.Healthy).To(g.BeTrue())
+		g.Expect(ent.Tags).To(g.Equal([]string{"store"}))
+		g.Expect(ent.Since).NotTo(g.BeZero())
+
+		db = false
+		rep = subject.Report()
+		g.Expect(rep.Healthy).To(g.BeFalse())
+		g.Expect(rep.Failing()).To(g.Equal([]string{"cache", "db"}))
+
+		next, _ := rep.Get("db")
+		g.Expect(next.Since).To(g.BeTemporally(">", ent.Since))
+	})
+
+	It("should check status", func() {
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusHealthy))
+		g.Expect(reason).To(g.BeEmpty())
+
+		cache = false
+		status, reason = subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusDegraded))
+		g.Expect(reason).To(g.Equal("cache is unhealthy"))
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+
+		g.Expect(subject.Register("queue", StatusCheckFunc(func() (Status, string) {
+			return StatusDegraded, "slow"
+		}), &CheckOptions{Critical: true})).To(g.Succeed())
+		db = false
+		status, reason = subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("cache is unhealthy, db is unhealthy, queue is degraded"))
+
+		rep := subject.Report()
+		g.Expect(rep.Status).To(g.Equal(StatusUnhealthy))
+		ent, _ := rep.Get("queue")
+		g.Expect(ent.Healthy).To(g.BeTrue())
+		g.Expect(ent.Status).To(g.Equal(StatusDegraded))
+		g.Expect(ent.Reason).To(g.Equal("slow"))
+
+		db = true
+		g.Expect(subject.Report().Status).To(g.Equal(StatusDegraded))
+	})
+
+	It("should reject cyclic dependencies", func() {
+		pass := CheckFunc(func() bool { return true })
+		g.Expect(subject.Register("a", pass, &CheckOptions{DependsOn: []string{"b"}})).To(g.Succeed())
+		g.Expect(subject.Register("b", pass, &CheckOptions{DependsOn: []string{"c", "db"}})).To(g.Succeed())
+		g.Expect(subject.Register("c", pass, &CheckOptions{DependsOn: []string{"a"}})).To(g.MatchError("health: cyclic dependency c -> a -> b -> c"))
+		g.Expect(subject.Register("c", pass, &CheckOptions{DependsOn: []string{"c"}})).To(g.MatchError("health: cyclic dependency c -> c"))
+		g.Expect(subject.Register("c", pass, &CheckOptions{DependsOn: []string{"db"}})).To(g.Succeed())
+	})
+
+	It("should report root causes", func() {
+		var api, web bool
+		g.Expect(subject.Register("api", CheckFunc(func() bool { return api }), &CheckOptions{DependsOn: []string{"db", "cache"}})).To(g.Succeed())
+		g.Expect(subject.Register("web", CheckFunc(func() bool { return web }), &CheckOptions{DependsOn: []string{"api", "missing"}})).To(g.Succeed())
+
+		db = false
+		rep := subject.Report()
+		g.Expect(rep.Failing()).To(g.Equal([]string{"api", "db", "web"}))
+		g.Expect(rep.RootCauses()).To(g.Equal([]string{"db"}))
+
+		ent, _ := rep.Get("web")
+		g.Expect(ent.DependsOn).To(g.Equal([]string{"api", "missing"}))
+		g.Expect(ent.BlockedBy).To(g.Equal([]string{"db"}))
+		g.Expect(ent.Reason).To(g.Equal("blocked by db"))
+
+		cache = false
+		ent, _ = subject.Report().Get("api")
+		g.Expect(ent.BlockedBy).To(g.Equal([]string{"cache", "db"}))
+		g.Expect(ent.Reason).To(g.Equal("blocked by cache, db"))
+
+		db, cache = true, true
+		rep = subject.Report()
+		g.Expect(rep.RootCauses()).To(g.Equal([]string{"api"}))
+		ent, _ = rep.Get("web")
+		g.Expect(ent.BlockedBy).To(g.Equal([]string{"api"}))
+	})
+
+	It("should filter reports", func() {
+		db = false
+
+		rep := subject.Report().Filter("store")
+		g.Expect(rep.Healthy).To(g.BeFalse())
+		g.Expect(rep.Checks).To(g.HaveLen(1))
+
+		rep = subject.Report().Filter("other")
+		g.Expect(rep.Healthy).To(g.BeTrue())
+		g.Expect(rep.Checks).To(g.BeEmpty())
+	})
+
+})
+
+func BenchmarkRegistry_IsHealthy(b *testing.B) {
+	reg := NewRegistry()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if err := reg.Register(name, CheckFunc(func() bool { return true }), &CheckOptions{Critical: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reg.IsHealthy()
+	}
+}
@@ -0,0 +1,168 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+// Threshold is a check which samples a value in the background and compares it
+// against a maximum. IsHealthy only reads the last sample and never blocks.
+type Threshold struct {
+	sample func() (float64, error)
+	max    float64
+
+	value   uint64 // float64 bits
+	healthy int32
+
+	lastErr error
+	mu      sync.Mutex
+
+	closer tomb.Tomb
+}
+
+// NewThreshold creates a check which calls sample every inter and is healthy
+// as long as the sampled value does not exceed max. A failed sample marks the
+// check as unhealthy. The first sample is taken immediately.
+func NewThreshold(sample func() (float64, error), max float64, inter time.Duration) *Threshold {
+	t := &Threshold{sample: sample, max: max}
+	t.update()
+	t.closer.Go(func() error { return t.loop(inter) })
+	return t
+}
+
+// NewGoroutineCheck creates a check which is healthy as long as the number
+// of goroutines does not exceed max.
+func NewGoroutineCheck(max int, inter time.Duration) *Threshold {
+	return NewThreshold(sampleGoroutines, float64(max), inter)
+}
+
+// NewHeapCheck creates a check which is healthy as long as the number
+// of allocated heap bytes does not exceed max.
+func NewHeapCheck(max uint64, inter time.Duration) *Threshold {
+	return NewThreshold(sampleHeap, float64(max), inter)
+}
+
+// NewGCPauseCheck creates a check which is healthy as long as the most
+// recent GC pause does not exceed max. Values are sampled in seconds.
+func NewGCPauseCheck(max time.Duration, inter time.Duration) *Threshold {
+	return NewThreshold(sampleGCPause, max.Seconds(), inter)
+}
+
+// NewFDCheck creates a check which is healthy as long as the number of open
+// file descriptors does not exceed max. File descriptors are counted via
+// /proc/self/fd and are therefore only available on Linux.
+func NewFDCheck(max int, inter time.Duration) *Threshold {
+	return NewThreshold(sampleFDs, float64(max), inter)
+}
+
+// IsHealthy implements Check interface
+func (t *Threshold) IsHealthy() bool {
+	return atomic.LoadInt32(&t.healthy) > 0
+}
+
+// CheckStatus implements StatusChecker interface
+func (t *Threshold) CheckStatus() (Status, string) {
+	if t.IsHealthy() {
+		return StatusHealthy, ""
+	}
+
+	t.mu.Lock()
+	err := t.lastErr
+	t.mu.Unlock()
+
+	if err != nil {
+		return StatusUnhealthy, err.Error()
+	}
+	return StatusUnhealthy, fmt.Sprintf("%s exceeds maximum of %s",
+		formatMetricValue(t.Value()), formatMetricValue(t.max))
+}
+
+// Value returns the last sampled value
+func (t *Threshold) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&t.value))
+}
+
+// Stop stops sampling
+func (t *Threshold) Stop() {
+	t.closer.Kill(nil)
+	t.closer.Wait()
+}
+
+func (t *Threshold) loop(inter time.Duration) error {
+	ticker := time.NewTicker(inter)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closer.Dying():
+			return nil
+		case <-ticker.C:
+			t.update()
+		}
+	}
+}
+
+func (t *Threshold) update() {
+	v, err := t.sample()
+
+	t.mu.Lock()
+	t.lastErr = err
+	t.mu.Unlock()
+
+	var healthy int32
+	if err == nil && v <= t.max {
+		healthy = 1
+	}
+	atomic.StoreUint64(&t.value, math.Float64bits(v))
+	atomic.StoreInt32(&t.healthy, healthy)
+}
+
+// --------------------------------------------------------------------
+
+func sampleGoroutines() (float64, error) {
+	return float64(runtime.NumGoroutine()), nil
+}
+
+func sampleHeap() (float64, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return float64(ms.HeapAlloc), nil
+}
+
+func sampleGCPause() (float64, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if ms.NumGC == 0 {
+		return 0, nil
+	}
+	return time.Duration(ms.PauseNs[(ms.NumGC+255)%256]).Seconds(), nil
+}
+
+func sampleFDs() (float64, error) {
+	dir, err := os.Open("/proc/self/fd")
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+
+	var n int
+	for {
+		names, err := dir.Readdirnames(256)
+		n += len(names)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	// exclude the descriptor used to read the directory
+	return float64(n - 1), nil
+}
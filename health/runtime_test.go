@@ -0,0 +1,93 @@
+package health
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Threshold", func() {
+	var subject *Threshold
+	var value float64
+	var err error
+
+	BeforeEach(func() {
+		value, err = 5, nil
+		subject = NewThreshold(func() (float64, error) { return value, err }, 10, time.Hour)
+	})
+
+	AfterEach(func() {
+		subject.Stop()
+	})
+
+	It("should sample immediately", func() {
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+		g.Expect(subject.Value()).To(g.Equal(5.0))
+	})
+
+	It("should compare samples", func() {
+		value = 12
+		subject.update()
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+		g.Expect(subject.Value()).To(g.Equal(12.0))
+
+		status, reason := subject.CheckStatus()
+		g.Expect(status).To(g.Equal(StatusUnhealthy))
+		g.Expect(reason).To(g.Equal("12 exceeds maximum of 10"))
+
+		value = 10
+		subject.update()
+		g.Expect(subject.IsHealthy()).To(g.BeTrue())
+	})
+
+	It("should fail on sample errors", func() {
+		err = errors.New("sampling failed")
+		subject.update()
+		g.Expect(subject.IsHealthy()).To(g.BeFalse())
+
+		_, reason := subject.CheckStatus()
+		g.Expect(reason).To(g.Equal("sampling failed"))
+	})
+
+	It("should sample periodically", func() {
+		var n int64 = 5
+		check := NewThreshold(func() (float64, error) { return float64(atomic.LoadInt64(&n)), nil }, 10, time.Millisecond)
+		defer check.Stop()
+
+		atomic.StoreInt64(&n, 20)
+		g.Eventually(check.IsHealthy, "20ms", "2ms").Should(g.BeFalse())
+	})
+
+	It("should check runtime metrics", func() {
+		goroutines := NewGoroutineCheck(1e6, time.Hour)
+		defer goroutines.Stop()
+		g.Expect(goroutines.IsHealthy()).To(g.BeTrue())
+		g.Expect(goroutines.Value()).To(g.BeNumerically(">", 0))
+
+		heap := NewHeapCheck(1, time.Hour)
+		defer heap.Stop()
+		g.Expect(heap.IsHealthy()).To(g.BeFalse())
+
+		runtime.GC()
+		pause := NewGCPauseCheck(time.Minute, time.Hour)
+		defer pause.Stop()
+		g.Expect(pause.IsHealthy()).To(g.BeTrue())
+		g.Expect(pause.Value()).To(g.BeNumerically(">", 0))
+	})
+
+	It("should count file descriptors", func() {
+		if runtime.GOOS != "linux" {
+			Skip("requires /proc")
+		}
+
+		fds := NewFDCheck(1e6, time.Hour)
+		defer fds.Stop()
+		g.Expect(fds.IsHealthy()).To(g.BeTrue())
+		g.Expect(fds.Value()).To(g.BeNumerically(">=", 3))
+	})
+
+})
@@ -0,0 +1,227 @@
+package health
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+// Scheduler drives many pings from a bounded pool of workers, instead of
+// running a separate goroutine for each ping. Scheduled pings retain their
+// individual intervals and rise/fall semantics.
+type Scheduler struct {
+	queue   scheduleQueue
+	entries map[*Ping]*scheduleEntry
+	mu      sync.Mutex
+
+	wakeup chan struct{}
+	jobs   chan *scheduleEntry
+	closer tomb.Tomb
+}
+
+// NewScheduler creates a new scheduler with a number of workers.
+// The number of workers limits the number of concurrent pings.
+func NewScheduler(workers int) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
+	s := &Scheduler{
+		entries: make(map[*Ping]*scheduleEntry),
+		wakeup:  make(chan struct{}, 1),
+		jobs:    make(chan *scheduleEntry),
+	}
+	s.closer.Go(s.loop)
+	for i := 0; i < workers; i++ {
+		s.closer.Go(s.work)
+	}
+	return s
+}
+
+// NewPing creates a continous ping health check, driven by the scheduler.
+// See NewPingWithOptions for details.
+func (s *Scheduler) NewPing(pinger func(context.Context) error, opts *PingOptions) *Ping {
+	ping := newPing(pinger, opts)
+	ping.sched = s
+
+	delay := time.Duration(0)
+	if !ping.immediate {
+		delay = ping.next()
+	}
+	s.schedule(ping, time.Now().Add(delay), true)
+	return ping
+}
+
+// Len returns the number of scheduled pings
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	n := len(s.entries)
+	s.mu.Unlock()
+	return n
+}
+
+// Stop stops the scheduler and all scheduled pings
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	for ping := range s.entries {
+		ping.cancel()
+	}
+	s.entries = make(map[*Ping]*scheduleEntry)
+	s.queue = s.queue[:0]
+	s.mu.Unlock()
+
+	s.closer.Kill(nil)
+	s.closer.Wait()
+}
+
+// schedule adds a ping to the queue. Unless add is true, the ping is
+// only rescheduled if it has not been removed in the meantime.
+func (s *Scheduler) schedule(ping *Ping, at time.Time, add bool) {
+	s.mu.Lock()
+	entry, ok := s.entries[ping]
+	if !ok && !add {
+		s.mu.Unlock()
+		return
+	}
+	if !ok {
+		entry = &scheduleEntry{ping: ping, index: -1}
+		s.entries[ping] = entry
+	}
+	entry.at = at
+	heap.Push(&s.queue, entry)
+	s.mu.Unlock()
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// remove unschedules a ping and waits for a running ping to complete
+func (s *Scheduler) remove(ping *Ping) {
+	s.mu.Lock()
+	entry, ok := s.entries[ping]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.entries, ping)
+	if entry.index > -1 {
+		heap.Remove(&s.queue, entry.index)
+	}
+	s.mu.Unlock()
+
+	entry.running.Wait()
+}
+
+// begin marks a popped entry as running, unless
+// its ping has been removed in the meantime
+func (s *Scheduler) begin(entry *scheduleEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[entry.ping] != entry {
+		return false
+	}
+	entry.running.Add(1)
+	return true
+}
+
+// next pops the next due entry or returns the delay until it is due
+func (s *Scheduler) next(now time.Time) (*scheduleEntry, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, time.Hour
+	}
+	if delay := s.queue[0].at.Sub(now); delay > 0 {
+		return nil, delay
+	}
+	return heap.Pop(&s.queue).(*scheduleEntry), 0
+}
+
+func (s *Scheduler) loop() error {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		entry, delay := s.next(time.Now())
+		if entry != nil {
+			select {
+			case <-s.closer.Dying():
+				return nil
+			case s.jobs <- entry:
+			}
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+
+		select {
+		case <-s.closer.Dying():
+			return nil
+		case <-s.wakeup:
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Scheduler) work() error {
+	for {
+		select {
+		case <-s.closer.Dying():
+			return nil
+		case entry := <-s.jobs:
+			if !s.begin(entry) {
+				continue
+			}
+			entry.ping.run()
+			entry.running.Done()
+			s.schedule(entry.ping, time.Now().Add(entry.ping.next()), false)
+		}
+	}
+}
+
+// --------------------------------------------------------------------
+
+type scheduleEntry struct {
+	ping    *Ping
+	at      time.Time
+	index   int
+	running sync.WaitGroup
+}
+
+type scheduleQueue []*scheduleEntry
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	entry := x.(*scheduleEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
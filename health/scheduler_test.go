@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scheduler", func() {
+	var subject *Scheduler
+
+	BeforeEach(func() {
+		subject = NewScheduler(2)
+	})
+
+	AfterEach(func() {
+		subject.Stop()
+	})
+
+	It("should drive pings", func() {
+		var calls int32
+		pings := make([]*Ping, 20)
+		for i := range pings {
+			pings[i] = subject.NewPing(func(_ context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			}, &PingOptions{Interval: time.Millisecond, Rise: 2})
+		}
+		g.Expect(subject.Len()).To(g.Equal(20))
+
+		for _, ping := range pings {
+			g.Eventually(ping.IsHealthy, "100ms", "2ms").Should(g.BeTrue())
+		}
+		g.Expect(atomic.LoadInt32(&calls)).To(g.BeNumerically(">=", 40))
+	})
+
+	It("should respect intervals", func() {
+		var fast, slow int32
+		subject.NewPing(func(_ context.Context) error {
+			atomic.AddInt32(&fast, 1)
+			return nil
+		}, &PingOptions{Interval: time.Millisecond})
+		subject.NewPing(func(_ context.Context) error {
+			atomic.AddInt32(&slow, 1)
+			return nil
+		}, &PingOptions{Interval: time.Hour, Immediate: true})
+
+		g.Eventually(func() int32 { return atomic.LoadInt32(&fast) }, "100ms", "2ms").Should(g.BeNumerically(">=", 10))
+		g.Expect(atomic.LoadInt32(&slow)).To(g.Equal(int32(1)))
+	})
+
+	It("should stop individual pings", func() {
+		var calls int32
+		ping := subject.NewPing(func(_ context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		}, &PingOptions{Interval: time.Millisecond})
+		g.Eventually(func() int32 { return atomic.LoadInt32(&calls) }, "100ms", "2ms").Should(g.BeNumerically(">", 0))
+
+		ping.Stop()
+		g.Expect(subject.Len()).To(g.Equal(0))
+
+		n := atomic.LoadInt32(&calls)
+		g.Consistently(func() int32 { return atomic.LoadInt32(&calls) }, "20ms", "2ms").Should(g.BeNumerically("<=", n+1))
+	})
+
+	It("should wait for running pings on stop", func() {
+		var started, finished int32
+		ping := subject.NewPing(func(_ context.Context) error {
+			atomic.StoreInt32(&started, 1)
+			time.Sleep(10 * time.Millisecond)
+			atomic.StoreInt32(&finished, 1)
+			return errPing
+		}, &PingOptions{Interval: time.Hour, Healthy: true, Immediate: true})
+
+		var changes int32
+		ping.OnChange(func(bool, error) { atomic.AddInt32(&changes, 1) })
+		g.Eventually(func() int32 { return atomic.LoadInt32(&started) }, "50ms", "1ms").Should(g.Equal(int32(1)))
+
+		ping.Stop()
+		g.Expect(atomic.LoadInt32(&finished)).To(g.Equal(int32(1)))
+		g.Expect(ping.IsHealthy()).To(g.BeTrue())
+		g.Expect(atomic.LoadInt32(&changes)).To(g.Equal(int32(0)))
+	})
+
+	It("should skip pings removed after being dequeued", func() {
+		sched := &Scheduler{entries: make(map[*Ping]*scheduleEntry), wakeup: make(chan struct{}, 1)}
+		ping := newPing(func(_ context.Context) error { return nil }, nil)
+		ping.sched = sched
+
+		sched.schedule(ping, time.Now(), true)
+		entry, _ := sched.next(time.Now())
+		g.Expect(entry.ping).To(g.Equal(ping))
+
+		ping.Stop()
+		g.Expect(sched.begin(entry)).To(g.BeFalse())
+	})
+
+	It("should bound concurrency", func() {
+		var running, max int32
+		for i := 0; i < 10; i++ {
+			subject.NewPing(func(_ context.Context) error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return nil
+			}, &PingOptions{Interval: time.Millisecond, Immediate: true})
+		}
+
+		g.Consistently(func() int32 { return atomic.LoadInt32(&max) }, "20ms", "2ms").Should(g.BeNumerically("<=", 2))
+		g.Expect(atomic.LoadInt32(&max)).To(g.Equal(int32(2)))
+	})
+
+})
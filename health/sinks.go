@@ -0,0 +1,192 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookOptions contain optional webhook settings
+type WebhookOptions struct {
+	// Client is the HTTP client to use.
+	// Default: a client with a 10s timeout
+	Client *http.Client
+	// Header contains additional request headers
+	Header http.Header
+	// Retries is the number of retries after a failed attempt.
+	// A negative value disables retries. Default: 3
+	Retries int
+	// Backoff is the initial delay between retries,
+	// doubled after each attempt. Default: 1s
+	Backoff time.Duration
+}
+
+func (o *WebhookOptions) norm() *WebhookOptions {
+	var oo WebhookOptions
+	if o != nil {
+		oo = *o
+	}
+
+	if oo.Client == nil {
+		oo.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if oo.Retries == 0 {
+		oo.Retries = 3
+	} else if oo.Retries < 0 {
+		oo.Retries = 0
+	}
+	if oo.Backoff <= 0 {
+		oo.Backoff = time.Second
+	}
+	return &oo
+}
+
+// WebhookSink creates a sink which POSTs events as JSON to a URL.
+// Failed requests and non-2xx responses are retried up to Retries times
+// with exponential backoff, retries stop early when the context is
+// cancelled. Options are optional and may be nil.
+func WebhookSink(url string, opts *WebhookOptions) Sink {
+	return &webhookSink{url: url, opts: opts.norm()}
+}
+
+type webhookSink struct {
+	url  string
+	opts *WebhookOptions
+}
+
+// Notify implements Sink interface
+func (s *webhookSink) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.opts.Backoff
+	for i := 0; ; i++ {
+		if err = s.post(ctx, body); err == nil || i >= s.opts.Retries {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (s *webhookSink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, vv := range s.opts.Header {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("health: webhook responded with %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------
+
+// LogSink creates a sink which writes events as structured
+// key=value lines to w, e.g.:
+//
+//	time=2017-06-01T12:00:00Z check=db status=unhealthy reason="connection refused"
+//
+// Events for the aggregate registry status are written with an empty check.
+func LogSink(w io.Writer) Sink {
+	return &logSink{w: w}
+}
+
+type logSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// Notify implements Sink interface
+func (s *logSink) Notify(_ context.Context, ev Event) error {
+	line := "time=" + ev.Time.UTC().Format(time.RFC3339) +
+		" check=" + logValue(ev.Name) +
+		" status=" + ev.Status.String()
+	if ev.Reason != "" {
+		line += " reason=" + logValue(ev.Reason)
+	}
+
+	s.mu.Lock()
+	_, err := io.WriteString(s.w, line+"\n")
+	s.mu.Unlock()
+	return err
+}
+
+func logValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// --------------------------------------------------------------------
+
+// ExecSink creates a sink which runs a local command for each event.
+// The event is passed as JSON on STDIN and as HEALTH_CHECK, HEALTH_STATUS,
+// HEALTH_REASON and HEALTH_TIME environment variables. The command is killed
+// if the context is cancelled.
+func ExecSink(name string, args ...string) Sink {
+	return &execSink{name: name, args: args}
+}
+
+type execSink struct {
+	name string
+	args []string
+}
+
+// Notify implements Sink interface
+func (s *execSink) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, s.name, s.args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"HEALTH_CHECK="+ev.Name,
+		"HEALTH_STATUS="+ev.Status.String(),
+		"HEALTH_REASON="+ev.Reason,
+		"HEALTH_TIME="+ev.Time.UTC().Format(time.RFC3339),
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("health: %s failed: %v: %s", s.name, err, msg)
+		}
+		return fmt.Errorf("health: %s failed: %v", s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sinks", func() {
+	ctx := context.Background()
+	event := Event{
+		Name:   "db",
+		Status: StatusUnhealthy,
+		Reason: "connection refused",
+		Time:   time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	Describe("WebhookSink", func() {
+		var server *httptest.Server
+		var attempts, failures int32
+		var received []Event
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&attempts, 0)
+			atomic.StoreInt32(&failures, 0)
+			received = nil
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				n := atomic.AddInt32(&attempts, 1)
+				g.Expect(r.Method).To(g.Equal("POST"))
+				g.Expect(r.Header.Get("Content-Type")).To(g.Equal("application/json"))
+				g.Expect(r.Header.Get("X-Token")).To(g.Equal("secret"))
+
+				if n <= atomic.LoadInt32(&failures) {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+
+				var ev Event
+				g.Expect(json.NewDecoder(r.Body).Decode(&ev)).To(g.Succeed())
+				received = append(received, ev)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newSink := func(retries int) Sink {
+			return WebhookSink(server.URL, &WebhookOptions{
+				Header:  http.Header{"X-Token": {"secret"}},
+				Retries: retries,
+				Backoff: time.Millisecond,
+			})
+		}
+
+		It("should post events", func() {
+			g.Expect(newSink(0).Notify(ctx, event)).To(g.Succeed())
+			g.Expect(received).To(g.HaveLen(1))
+			g.Expect(received[0].Name).To(g.Equal("db"))
+			g.Expect(received[0].Status).To(g.Equal(StatusUnhealthy))
+			g.Expect(received[0].Reason).To(g.Equal("connection refused"))
+			g.Expect(received[0].Time.Equal(event.Time)).To(g.BeTrue())
+		})
+
+		It("should retry", func() {
+			atomic.StoreInt32(&failures, 2)
+			g.Expect(newSink(2).Notify(ctx, event)).To(g.Succeed())
+			g.Expect(atomic.LoadInt32(&attempts)).To(g.Equal(int32(3)))
+			g.Expect(received).To(g.HaveLen(1))
+		})
+
+		It("should give up eventually", func() {
+			atomic.StoreInt32(&failures, 5)
+			g.Expect(newSink(2).Notify(ctx, event)).To(g.MatchError("health: webhook responded with 502"))
+			g.Expect(atomic.LoadInt32(&attempts)).To(g.Equal(int32(3)))
+
+			atomic.StoreInt32(&attempts, 0)
+			g.Expect(newSink(-1).Notify(ctx, event)).NotTo(g.Succeed())
+			g.Expect(atomic.LoadInt32(&attempts)).To(g.Equal(int32(1)))
+		})
+
+		It("should stop retrying when cancelled", func() {
+			atomic.StoreInt32(&failures, 5)
+			sink := WebhookSink(server.URL, &WebhookOptions{
+				Header:  http.Header{"X-Token": {"secret"}},
+				Backoff: time.Hour,
+			})
+
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			g.Expect(sink.Notify(ctx, event)).To(g.Equal(context.DeadlineExceeded))
+			g.Expect(time.Since(start)).To(g.BeNumerically("<", time.Second))
+			g.Expect(atomic.LoadInt32(&attempts)).To(g.Equal(int32(1)))
+		})
+	})
+
+	It("should log events", func() {
+		buf := new(bytes.Buffer)
+		sink := LogSink(buf)
+		g.Expect(sink.Notify(ctx, event)).To(g.Succeed())
+		g.Expect(sink.Notify(ctx, Event{Status: StatusHealthy, Time: event.Time})).To(g.Succeed())
+		g.Expect(buf.String()).To(g.Equal(`time=2017-06-01T12:00:00Z check=db status=unhealthy reason="connection refused"` + "\n" +
+			`time=2017-06-01T12:00:00Z check="" status=healthy` + "\n"))
+	})
+
+	It("should exec commands", func() {
+		dir, err := ioutil.TempDir("", "health-sinks")
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "out")
+		sink := ExecSink("sh", "-c", `echo "$HEALTH_CHECK $HEALTH_STATUS $HEALTH_REASON" > `+path+` && cat >> `+path)
+		g.Expect(sink.Notify(ctx, event)).To(g.Succeed())
+
+		data, err := ioutil.ReadFile(path)
+		g.Expect(err).NotTo(g.HaveOccurred())
+		g.Expect(string(data)).To(g.HavePrefix("db unhealthy connection refused\n{\"name\":\"db\""))
+
+		sink = ExecSink("sh", "-c", "echo oops >&2; exit 3")
+		g.Expect(sink.Notify(ctx, event)).To(g.MatchError("health: sh failed: exit status 3: oops"))
+	})
+
+})
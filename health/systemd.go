@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/tomb.v2"
+)
+
+// SDNotify sends a state notification to the systemd service manager, e.g.
+// "READY=1" or "STOPPING=1". The socket is taken from the NOTIFY_SOCKET
+// environment variable, abstract socket names starting with '@' are
+// supported. SDNotify is a no-op if NOTIFY_SOCKET is not set.
+func SDNotify(state string) error {
+	return sdNotify(os.Getenv("NOTIFY_SOCKET"), state)
+}
+
+func sdNotify(socket, state string) error {
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// Systemd integrates a registry with the systemd service manager. It sends
+// READY=1 once all critical checks pass and, if the watchdog is enabled via
+// WatchdogSec=, sends WATCHDOG=1 at half the watchdog interval for as long as
+// the registry is healthy. This allows systemd to restart wedged processes.
+//
+// Services should be configured with Type=notify, e.g.:
+//
+//	[Service]
+//	Type=notify
+//	WatchdogSec=30s
+type Systemd struct {
+	reg      *Registry
+	socket   string
+	watchdog time.Duration
+
+	closer tomb.Tomb
+}
+
+// NewSystemd starts the systemd integration. Settings are taken from the
+// NOTIFY_SOCKET, WATCHDOG_USEC and WATCHDOG_PID environment variables.
+// No notifications are sent if NOTIFY_SOCKET is not set.
+func NewSystemd(reg *Registry) *Systemd {
+	return newSystemd(reg, os.Getenv("NOTIFY_SOCKET"), watchdogInterval())
+}
+
+func newSystemd(reg *Registry, socket string, watchdog time.Duration) *Systemd {
+	s := &Systemd{reg: reg, socket: socket, watchdog: watchdog}
+	s.closer.Go(s.loop)
+	return s
+}
+
+// Stop stops the integration. It returns the first
+// error that occurred while sending notifications.
+func (s *Systemd) Stop() error {
+	s.closer.Kill(nil)
+	return s.closer.Wait()
+}
+
+func (s *Systemd) loop() error {
+	if s.socket == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closer.Dying():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := s.reg.WaitHealthy(ctx); err != nil {
+		return nil // stopped
+	}
+	if err := sdNotify(s.socket, "READY=1"); err != nil {
+		return err
+	}
+	if s.watchdog <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.watchdog / 2)
+	defer ticker.Stop()
+
+	for {
+		if s.reg.IsHealthy() {
+			if err := sdNotify(s.socket, "WATCHDOG=1"); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-s.closer.Dying():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchdogInterval parses WATCHDOG_USEC, it returns 0 if
+// the watchdog is disabled or intended for another process
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("Systemd", func() {
+	var dir, socket string
+	var lis *net.UnixConn
+	var reg *Registry
+	var db int32
+
+	recv := func(timeout time.Duration) string {
+		buf := make([]byte, 256)
+		_ = lis.SetReadDeadline(time.Now().Add(timeout))
+		n, err := lis.Read(buf)
+		if err != nil {
+			return ""
+		}
+		return string(buf[:n])
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "health-systemd")
+		g.Expect(err).NotTo(g.HaveOccurred())
+
+		socket = filepath.Join(dir, "notify.sock")
+		lis, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+		g.Expect(err).NotTo(g.HaveOccurred())
+
+		atomic.StoreInt32(&db, 0)
+		reg = NewRegistry()
+		g.Expect(reg.Register("db", CheckFunc(func() bool { return atomic.LoadInt32(&db) > 0 }), &CheckOptions{Critical: true})).To(g.Succeed())
+	})
+
+	AfterEach(func() {
+		g.Expect(lis.Close()).To(g.Succeed())
+		g.Expect(os.RemoveAll(dir)).To(g.Succeed())
+		os.Unsetenv("NOTIFY_SOCKET")
+		os.Unsetenv("WATCHDOG_USEC")
+		os.Unsetenv("WATCHDOG_PID")
+	})
+
+	It("should notify", func() {
+		g.Expect(SDNotify("READY=1")).To(g.Succeed())
+
+		os.Setenv("NOTIFY_SOCKET", socket)
+		g.Expect(SDNotify("STATUS=testing")).To(g.Succeed())
+		g.Expect(recv(time.Second)).To(g.Equal("STATUS=testing"))
+	})
+
+	It("should support abstract sockets", func() {
+		if runtime.GOOS != "linux" {
+			Skip("requires linux")
+		}
+
+		name := "@flood-health-" + strconv.Itoa(os.Getpid())
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"})
+		g.Expect(err).NotTo(g.HaveOccurred())
+		defer conn.Close()
+
+		g.Expect(sdNotify(name, "READY=1")).To(g.Succeed())
+		buf := make([]byte, 256)
+		n, err := conn.Read(buf)
+		g.Expect(err).NotTo(g.HaveOccurred())
+		g.Expect(string(buf[:n])).To(g.Equal("READY=1"))
+	})
+
+	It("should parse watchdog settings", func() {
+		g.Expect(watchdogInterval()).To(g.Equal(time.Duration(0)))
+
+		os.Setenv("WATCHDOG_USEC", "30000000")
+		g.Expect(watchdogInterval()).To(g.Equal(30 * time.Second))
+
+		os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+		g.Expect(watchdogInterval()).To(g.Equal(30 * time.Second))
+
+		os.Setenv("WATCHDOG_PID", "1")
+		g.Expect(watchdogInterval()).To(g.Equal(time.Duration(0)))
+	})
+
+	It("should send READY once critical checks pass", func() {
+		os.Setenv("NOTIFY_SOCKET", socket)
+		subject := NewSystemd(reg)
+		defer subject.Stop()
+
+		g.Expect(recv(50 * time.Millisecond)).To(g.BeEmpty())
+
+		atomic.StoreInt32(&db, 1)
+		g.Expect(recv(time.Second)).To(g.Equal("READY=1"))
+		g.Expect(recv(50 * time.Millisecond)).To(g.BeEmpty())
+		g.Expect(subject.Stop()).To(g.Succeed())
+	})
+
+	It("should send WATCHDOG while healthy", func() {
+		atomic.StoreInt32(&db, 1)
+		subject := newSystemd(reg, socket, 20*time.Millisecond)
+		defer subject.Stop()
+
+		g.Expect(recv(time.Second)).To(g.Equal("READY=1"))
+		g.Expect(recv(time.Second)).To(g.Equal("WATCHDOG=1"))
+		g.Expect(recv(time.Second)).To(g.Equal("WATCHDOG=1"))
+
+		atomic.StoreInt32(&db, 0)
+		time.Sleep(20 * time.Millisecond)
+		for recv(5*time.Millisecond) != "" {
+		}
+		g.Expect(recv(50 * time.Millisecond)).To(g.BeEmpty())
+
+		atomic.StoreInt32(&db, 1)
+		g.Expect(recv(time.Second)).To(g.Equal("WATCHDOG=1"))
+	})
+
+	It("should be a no-op without socket", func() {
+		subject := NewSystemd(reg)
+		g.Expect(subject.Stop()).To(g.Succeed())
+	})
+
+})
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// waitInterval is the interval at which checks are polled while waiting
+const waitInterval = 10 * time.Millisecond
+
+// WaitError is returned when the context expires before all checks pass
+type WaitError struct {
+	// Failing contains the names of checks which were still failing
+	Failing []string
+	// Err is the context error
+	Err error
+}
+
+// Error implements error interface
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("health: %v while waiting for %s", e.Err, strings.Join(e.Failing, ", "))
+}
+
+// WaitHealthy blocks until all named checks are healthy or the context
+// expires. In the latter case, a *WaitError is returned, listing the names
+// of the checks which were still failing, e.g.:
+//
+//	err := health.WaitHealthy(ctx, map[string]health.Check{
+//		"db":    dbPing,
+//		"cache": cachePing,
+//	})
+//
+// See Registry.WaitHealthy to wait for registered checks.
+func WaitHealthy(ctx context.Context, checks map[string]Check) error {
+	return waitHealthy(ctx, func() []string {
+		var failing []string
+		for name, c := range checks {
+			if !c.IsHealthy() {
+				failing = append(failing, name)
+			}
+		}
+		sort.Strings(failing)
+		return failing
+	})
+}
+
+// WaitHealthy blocks until all critical checks are healthy or the context
+// expires. In the latter case, a *WaitError is returned.
+func (r *Registry) WaitHealthy(ctx context.Context) error {
+	return waitHealthy(ctx, func() []string {
+		var failing []string
+		for _, e := range r.snapshot() {
+			if e.critical && !e.check.IsHealthy() {
+				failing = append(failing, e.name)
+			}
+		}
+		return failing
+	})
+}
+
+// WaitHealthy blocks until the ping is healthy or the context expires.
+// In the latter case, a *WaitError is returned.
+func (p *Ping) WaitHealthy(ctx context.Context) error {
+	return WaitHealthy(ctx, map[string]Check{"ping": p})
+}
+
+func waitHealthy(ctx context.Context, failing func() []string) error {
+	ticker := time.NewTicker(waitInterval)
+	defer ticker.Stop()
+
+	for {
+		names := failing()
+		if len(names) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &WaitError{Failing: names, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
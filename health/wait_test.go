@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitHealthy", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should return when checks pass", func() {
+		var n int32
+		check := CheckFunc(func() bool { return atomic.AddInt32(&n, 1) > 2 })
+
+		g.Expect(WaitHealthy(ctx, map[string]Check{
+			"pass":  CheckFunc(func() bool { return true }),
+			"check": check,
+		})).To(g.Succeed())
+		g.Expect(atomic.LoadInt32(&n)).To(g.Equal(int32(3)))
+	})
+
+	It("should fail when context expires", func() {
+		pass := CheckFunc(func() bool { return true })
+		fail := CheckFunc(func() bool { return false })
+
+		err := WaitHealthy(ctx, map[string]Check{"db": pass, "redis": fail, "cache": fail})
+		g.Expect(err).To(g.MatchError("health: context deadline exceeded while waiting for cache, redis"))
+		g.Expect(err.(*WaitError).Failing).To(g.Equal([]string{"cache", "redis"}))
+		g.Expect(err.(*WaitError).Err).To(g.Equal(context.DeadlineExceeded))
+	})
+
+	It("should wait for registries", func() {
+		reg := NewRegistry()
+		g.Expect(reg.Register("db", CheckFunc(func() bool { return false }), &CheckOptions{Critical: true})).To(g.Succeed())
+		g.Expect(reg.Register("cache", CheckFunc(func() bool { return false }), nil)).To(g.Succeed())
+		g.Expect(reg.WaitHealthy(ctx)).To(g.MatchError("health: context deadline exceeded while waiting for db"))
+
+		reg.Unregister("db")
+		g.Expect(reg.WaitHealthy(ctx)).To(g.Succeed())
+	})
+
+	It("should wait for pings", func() {
+		ping := NewPingWithOptions(func(_ context.Context) error {
+			return nil
+		}, &PingOptions{Interval: time.Millisecond, Rise: 2})
+		defer ping.Stop()
+
+		g.Expect(ping.WaitHealthy(ctx)).To(g.Succeed())
+	})
+
+})
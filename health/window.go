@@ -0,0 +1,132 @@
+package health
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// sample is an individual probe outcome
+type sample struct {
+	latency time.Duration
+	ok      bool
+}
+
+// window is a fixed-size ring of the most recent samples.
+// It is not thread-safe.
+type window struct {
+	samples []sample
+	pos     int
+	full    bool
+}
+
+func newWindow(size int) *window {
+	return &window{samples: make([]sample, size)}
+}
+
+// Add adds a sample, evicting the oldest one
+func (w *window) Add(s sample) {
+	if len(w.samples) == 0 {
+		return
+	}
+
+	w.samples[w.pos] = s
+	if w.pos++; w.pos == len(w.samples) {
+		w.pos, w.full = 0, true
+	}
+}
+
+// Len returns the number of samples in the window
+func (w *window) Len() int {
+	if w.full {
+		return len(w.samples)
+	}
+	return w.pos
+}
+
+// Full returns true if the window is filled
+func (w *window) Full() bool {
+	return w.full
+}
+
+// Each iterates over samples, oldest first
+func (w *window) Each(fn func(sample)) {
+	if w.full {
+		for _, s := range w.samples[w.pos:] {
+			fn(s)
+		}
+	}
+	for _, s := range w.samples[:w.pos] {
+		fn(s)
+	}
+}
+
+// SuccessRatio returns the ratio of successful samples
+func (w *window) SuccessRatio() float64 {
+	n := w.Len()
+	if n == 0 {
+		return 0
+	}
+
+	var ok int
+	w.Each(func(s sample) {
+		if s.ok {
+			ok++
+		}
+	})
+	return float64(ok) / float64(n)
+}
+
+// StateChange returns the weighted percentage of changes between successive
+// outcomes, ranging from 0 to 1. Like Nagios' flap detection, weights increase
+// linearly from 0.8 for the oldest to 1.2 for the most recent change.
+func (w *window) StateChange() float64 {
+	n := w.Len()
+	if n < 2 {
+		return 0
+	}
+
+	var sum float64
+	var prev bool
+	i := 0
+	w.Each(func(s sample) {
+		if i != 0 && s.ok != prev {
+			weight := 1.0
+			if n > 2 {
+				weight = 0.8 + 0.4*float64(i-1)/float64(n-2)
+			}
+			sum += weight
+		}
+		prev = s.ok
+		i++
+	})
+	return sum / float64(n-1)
+}
+
+// Percentiles returns latency percentiles for each of the given quantiles
+func (w *window) Percentiles(qs ...float64) []time.Duration {
+	lats := make(durationSlice, 0, w.Len())
+	w.Each(func(s sample) { lats = append(lats, s.latency) })
+	sort.Sort(lats)
+
+	res := make([]time.Duration, len(qs))
+	if len(lats) == 0 {
+		return res
+	}
+	for i, q := range qs {
+		n := int(math.Ceil(q*float64(len(lats)))) - 1
+		if n < 0 {
+			n = 0
+		} else if n >= len(lats) {
+			n = len(lats) - 1
+		}
+		res[i] = lats[n]
+	}
+	return res
+}
+
+type durationSlice []time.Duration
+
+func (p durationSlice) Len() int           { return len(p) }
+func (p durationSlice) Less(i, j int) bool { return p[i] < p[j] }
+func (p durationSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
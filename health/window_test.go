@@ -0,0 +1,54 @@
+package health
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	g "github.com/onsi/gomega"
+)
+
+var _ = Describe("window", func() {
+	var subject *window
+
+	BeforeEach(func() {
+		subject = newWindow(4)
+	})
+
+	It("should add samples", func() {
+		g.Expect(subject.Len()).To(g.Equal(0))
+		g.Expect(subject.SuccessRatio()).To(g.Equal(0.0))
+		g.Expect(subject.Percentiles(0.5)).To(g.Equal([]time.Duration{0}))
+
+		for i := 1; i <= 6; i++ {
+			subject.Add(sample{latency: time.Duration(i), ok: i%2 == 0})
+		}
+		g.Expect(subject.Len()).To(g.Equal(4))
+
+		var lats []time.Duration
+		subject.Each(func(s sample) { lats = append(lats, s.latency) })
+		g.Expect(lats).To(g.Equal([]time.Duration{3, 4, 5, 6}))
+	})
+
+	It("should calculate statistics", func() {
+		subject.Add(sample{latency: 4, ok: true})
+		subject.Add(sample{latency: 1, ok: false})
+		subject.Add(sample{latency: 3, ok: true})
+		g.Expect(subject.SuccessRatio()).To(g.BeNumerically("~", 0.667, 0.001))
+		g.Expect(subject.Percentiles(0, 0.5, 0.99, 1)).To(g.Equal([]time.Duration{1, 3, 4, 4}))
+	})
+
+	It("should calculate state changes", func() {
+		g.Expect(subject.StateChange()).To(g.Equal(0.0))
+
+		for _, ok := range []bool{true, false, true, true} {
+			subject.Add(sample{ok: ok})
+		}
+		// changes at positions 1 and 2, weights 0.8 and 1.0
+		g.Expect(subject.StateChange()).To(g.BeNumerically("~", 0.6, 0.001))
+
+		subject.Add(sample{ok: false})
+		// changes at positions 1 and 3, weights 0.8 and 1.2
+		g.Expect(subject.StateChange()).To(g.BeNumerically("~", 0.667, 0.001))
+	})
+
+})